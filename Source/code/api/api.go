@@ -0,0 +1,310 @@
+// Package api プラグインのプロセス内で動作するローカルHTTPサーバー
+//
+// Stream Deckと同じATEMとの接続を共有し、自動化スクリプトや外部ツールから
+// スイッチングの実行と状態の取得を行えるようにする。
+// 状態変化はWebSocket(GET /switchers/{name}/events)でも配信する。
+//
+// 全てのリクエストでトークンを要求する。ブラウザ上の任意のページから操作されないよう、
+// Originヘッダーを持つリクエストは許可したOriginからのもののみを受け付け、
+// POSTはContent-Typeがapplication/jsonのボディのみを受け付ける。
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/logger"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"golang.org/x/xerrors"
+)
+
+// DefaultAddress 待ち受けアドレスの既定値。既定ではローカルホストからのみ接続を受け付ける
+const DefaultAddress = "127.0.0.1:8765"
+
+var (
+	// ErrSwitcherNotFound スイッチャーが見つからない
+	ErrSwitcherNotFound = xerrors.New("スイッチャーが見つかりません")
+	// ErrNotConnected スイッチャーに接続していない
+	ErrNotConnected = xerrors.New("スイッチャーに接続していません")
+	// ErrLocked パネルロック中のため操作できない
+	ErrLocked = xerrors.New("パネルがロックされています")
+	// ErrUnknownInput スイッチャーのビデオソースとして定義されていない入力番号
+	ErrUnknownInput = xerrors.New("入力番号が不正です")
+)
+
+// Controller スイッチャーの操作。名前はグローバル設定の名前付きスイッチャーの名前のみを受け付ける
+type Controller interface {
+	// Switchers 名前付きスイッチャーの一覧
+	Switchers() []string
+	State(ctx context.Context, name string) (state.Switcher, error)
+	Cut(ctx context.Context, name string, meIndex uint8) error
+	Auto(ctx context.Context, name string, meIndex uint8) error
+	SetProgram(ctx context.Context, name string, meIndex uint8, input uint16) error
+	SetPreview(ctx context.Context, name string, meIndex uint8, input uint16) error
+}
+
+// Config サーバーの設定
+type Config struct {
+	Address string // 空の場合はDefaultAddress
+	Token   string // 必須
+	// AllowedOrigins ブラウザから接続を許可するOriginのホストのパターン。空の場合はブラウザからの接続を拒否する
+	AllowedOrigins []string
+	Controller     Controller
+	Logger         logger.Logger // nilの場合はログを出力しない
+}
+
+// Server ローカルHTTPサーバー
+type Server interface {
+	Addr() net.Addr
+	// Handle 認証付きのハンドラーを追加する。Runの前に呼び出す
+	Handle(pattern string, handler http.Handler)
+	// Run ctxが終了するかCloseされるまで接続を受け付ける
+	Run(ctx context.Context) error
+	Close() error
+}
+
+// NewServer サーバーを初期化し、ポートをbindする
+func NewServer(cfg Config) (Server, error) {
+	if cfg.Controller == nil {
+		return nil, xerrors.New("Controllerが指定されていません")
+	}
+	if cfg.Token == "" {
+		return nil, xerrors.New("トークンが指定されていません")
+	}
+	if cfg.Address == "" {
+		cfg.Address = DefaultAddress
+	}
+
+	listener, err := net.Listen("tcp", cfg.Address)
+	if err != nil {
+		return nil, xerrors.Errorf("HTTPの待ち受けに失敗: %w", err)
+	}
+
+	s := &server{
+		cfg:      cfg,
+		listener: listener,
+		mux:      http.NewServeMux(),
+	}
+	s.mux.HandleFunc("GET /switchers", s.handleSwitchers)
+	s.mux.HandleFunc("GET /switchers/{name}/state", s.handleState)
+	s.mux.HandleFunc("GET /switchers/{name}/events", s.handleEvents)
+	s.mux.HandleFunc("POST /switchers/{name}/cut", s.handleCut)
+	s.mux.HandleFunc("POST /switchers/{name}/auto", s.handleAuto)
+	s.mux.HandleFunc("POST /switchers/{name}/program", s.handleProgram)
+	s.mux.HandleFunc("POST /switchers/{name}/preview", s.handlePreview)
+	s.httpServer = &http.Server{
+		Handler:           s.authenticate(s.mux),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s, nil
+}
+
+type server struct {
+	cfg        Config
+	listener   net.Listener
+	mux        *http.ServeMux
+	httpServer *http.Server
+	closeOnce  sync.Once
+}
+
+func (s *server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+func (s *server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+func (s *server) Run(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+	if err := s.httpServer.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return xerrors.Errorf("HTTPサーバーの実行に失敗: %w", err)
+	}
+	return nil
+}
+
+func (s *server) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		err = s.httpServer.Close()
+	})
+	return err
+}
+
+func (s *server) debug(ctx context.Context, format string, args ...any) {
+	if s.cfg.Logger == nil {
+		return
+	}
+	s.cfg.Logger.Debug(ctx, format, args...)
+}
+
+// tokenProtocolPrefix WebSocketのサブプロトコルでトークンを渡す場合の接頭辞
+// ブラウザのWebSocketはヘッダーを指定できないため、"token.<token>"をサブプロトコルとして指定する
+const tokenProtocolPrefix = "token."
+
+// authenticate Originとトークンを検証する
+// トークンは"Authorization: Bearer <token>"か"X-API-Token"ヘッダー、WebSocketの場合はサブプロトコルで指定する
+func (s *server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && !originAllowed(origin, s.cfg.AllowedOrigins) {
+			writeError(w, http.StatusForbidden, xerrors.Errorf("Origin %s は許可されていません", origin))
+			return
+		}
+		token := r.Header.Get("X-API-Token")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if token == "" {
+			token = strings.TrimPrefix(tokenProtocol(r), tokenProtocolPrefix)
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, xerrors.New("トークンが一致しません"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tokenProtocol WebSocketのサブプロトコルのうち、トークンを渡すものを返す
+func tokenProtocol(r *http.Request) string {
+	for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); strings.HasPrefix(p, tokenProtocolPrefix) {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// originAllowed Originのホストがパターンのいずれかに一致するか
+func originAllowed(origin string, patterns []string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := strings.ToLower(u.Host)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// switchRequest POSTリクエストのボディ
+type switchRequest struct {
+	MeIndex uint8   `json:"me"`
+	Input   *uint16 `json:"input"` // 0(Black)と区別するため、未指定の場合はnil
+}
+
+// parseSwitchRequest application/jsonのボディからswitchRequestを取得する
+// ブラウザがプリフライト無しで送信できる形式を受け付けないよう、クエリやフォームは受け付けない
+func parseSwitchRequest(r *http.Request, needInput bool) (switchRequest, error) {
+	var req switchRequest
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return req, xerrors.New("Content-Typeはapplication/jsonである必要があります")
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, xerrors.Errorf("ボディの解析に失敗: %w", err)
+	}
+	if needInput && req.Input == nil {
+		return req, xerrors.New("inputが指定されていません")
+	}
+	return req, nil
+}
+
+func (s *server) handleSwitchers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"switchers": s.cfg.Controller.Switchers()})
+}
+
+func (s *server) handleState(w http.ResponseWriter, r *http.Request) {
+	st, err := s.cfg.Controller.State(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, st.Snapshot())
+}
+
+func (s *server) handleCut(w http.ResponseWriter, r *http.Request) {
+	s.handleSwitch(w, r, false, func(ctx context.Context, name string, req switchRequest) error {
+		return s.cfg.Controller.Cut(ctx, name, req.MeIndex)
+	})
+}
+
+func (s *server) handleAuto(w http.ResponseWriter, r *http.Request) {
+	s.handleSwitch(w, r, false, func(ctx context.Context, name string, req switchRequest) error {
+		return s.cfg.Controller.Auto(ctx, name, req.MeIndex)
+	})
+}
+
+func (s *server) handleProgram(w http.ResponseWriter, r *http.Request) {
+	s.handleSwitch(w, r, true, func(ctx context.Context, name string, req switchRequest) error {
+		return s.cfg.Controller.SetProgram(ctx, name, req.MeIndex, *req.Input)
+	})
+}
+
+func (s *server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	s.handleSwitch(w, r, true, func(ctx context.Context, name string, req switchRequest) error {
+		return s.cfg.Controller.SetPreview(ctx, name, req.MeIndex, *req.Input)
+	})
+}
+
+// handleSwitch スイッチング系のリクエストを共通の手順で処理する
+func (s *server) handleSwitch(w http.ResponseWriter, r *http.Request, needInput bool, f func(ctx context.Context, name string, req switchRequest) error) {
+	ctx := r.Context()
+	name := r.PathValue("name")
+	req, err := parseSwitchRequest(r, needInput)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.debug(ctx, "%s %s %#v", r.Method, r.URL.Path, req)
+
+	if err := f(ctx, name, req); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+// statusOf エラーに対応するHTTPステータス
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, ErrSwitcherNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrNotConnected):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrLocked):
+		return http.StatusLocked
+	case errors.Is(err, ErrUnknownInput):
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]any{"error": err.Error()})
+}
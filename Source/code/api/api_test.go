@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+)
+
+// fakeController 最後にPGMに設定された入力を記録する
+type fakeController struct {
+	program *uint16
+}
+
+func (c *fakeController) Switchers() []string { return []string{"main"} }
+
+func (c *fakeController) State(ctx context.Context, name string) (state.Switcher, error) {
+	return state.NewSwitcher(), nil
+}
+
+func (c *fakeController) Cut(ctx context.Context, name string, meIndex uint8) error  { return nil }
+func (c *fakeController) Auto(ctx context.Context, name string, meIndex uint8) error { return nil }
+
+func (c *fakeController) SetProgram(ctx context.Context, name string, meIndex uint8, input uint16) error {
+	c.program = &input
+	return nil
+}
+
+func (c *fakeController) SetPreview(ctx context.Context, name string, meIndex uint8, input uint16) error {
+	return nil
+}
+
+func TestNewServerRequiresToken(t *testing.T) {
+	if _, err := NewServer(Config{Address: "127.0.0.1:0", Controller: &fakeController{}}); err == nil {
+		t.Error("トークンなしで起動できました")
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	controller := &fakeController{}
+	s, err := NewServer(Config{
+		Address:        "127.0.0.1:0",
+		Token:          "secret",
+		AllowedOrigins: []string{"localhost:3000"},
+		Controller:     controller,
+	})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer s.Close()
+	handler := s.(*server).httpServer.Handler
+
+	tests := []struct {
+		name   string
+		url    string
+		body   string
+		header map[string]string
+		want   int
+	}{
+		{
+			name: "トークンなし",
+			url:  "/switchers/main/program",
+			body: `{"input":1}`,
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "クエリのトークンは受け付けない",
+			url:  "/switchers/main/program?token=secret",
+			body: `{"input":1}`,
+			want: http.StatusUnauthorized,
+		},
+		{
+			name:   "クエリの入力は受け付けない",
+			url:    "/switchers/main/program?input=1",
+			header: map[string]string{"X-API-Token": "secret", "Content-Type": "application/json"},
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "フォームは受け付けない",
+			url:    "/switchers/main/program",
+			body:   `{"input":1}`,
+			header: map[string]string{"X-API-Token": "secret", "Content-Type": "text/plain"},
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "許可されていないOrigin",
+			url:    "/switchers/main/program",
+			body:   `{"input":1}`,
+			header: map[string]string{"X-API-Token": "secret", "Content-Type": "application/json", "Origin": "https://evil.example.com"},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "許可されたOrigin",
+			url:    "/switchers/main/program",
+			body:   `{"input":1}`,
+			header: map[string]string{"Authorization": "Bearer secret", "Content-Type": "application/json", "Origin": "http://localhost:3000"},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "Originなし",
+			url:    "/switchers/main/program",
+			body:   `{"input":0}`,
+			header: map[string]string{"X-API-Token": "secret", "Content-Type": "application/json; charset=utf-8"},
+			want:   http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller.program = nil
+			r := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if (w.Code == http.StatusOK) != (controller.program != nil) {
+				t.Errorf("成功しなかったリクエストでスイッチングしました")
+			}
+		})
+	}
+}
+
+func TestTokenProtocol(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/switchers/main/events", nil)
+	r.Header.Set("Sec-WebSocket-Protocol", "json, token.secret")
+	if got := tokenProtocol(r); got != "token.secret" {
+		t.Errorf("tokenProtocol = %q", got)
+	}
+}
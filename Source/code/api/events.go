@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"golang.org/x/xerrors"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+const (
+	// eventBuffer クライアントごとの未送信イベントの上限。超えた場合は接続を閉じる
+	eventBuffer = 256
+	// eventWriteTimeout 1メッセージの送信タイムアウト
+	eventWriteTimeout = 5 * time.Second
+)
+
+// streamEventTypes WebSocketで配信するイベントの既定値
+// ATEMクライアントが状態を受信できるイベントのみを配信する
+var streamEventTypes = []state.EventType{
+	state.EventConnection,
+	state.EventProgram,
+	state.EventPreview,
+	state.EventInput,
+}
+
+// eventMessage WebSocketで送信するメッセージ
+// 接続直後にtypeが"snapshot"のメッセージで現在の状態を送信し、以降は状態変化ごとに送信する
+type eventMessage struct {
+	Type     string    `json:"type"`
+	Switcher string    `json:"switcher"`
+	Time     time.Time `json:"time"`
+	Data     any       `json:"data"`
+}
+
+// handleEvents GET /switchers/{name}/events 状態変化をWebSocketで配信する
+// typesクエリで配信するイベントを"program,preview"のように絞り込める
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	st, err := s.cfg.Controller.State(r.Context(), name)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	types := streamEventTypes
+	if v := r.URL.Query().Get("types"); v != "" {
+		types = nil
+		for _, t := range strings.Split(v, ",") {
+			types = append(types, state.EventType(strings.TrimSpace(t)))
+		}
+	}
+
+	// ブラウザソース等のオーバーレイは許可したOriginからのみ接続できる
+	opts := &websocket.AcceptOptions{OriginPatterns: s.cfg.AllowedOrigins}
+	if p := tokenProtocol(r); p != "" {
+		// ブラウザはサブプロトコルが選択されない場合に接続を失敗させる
+		opts.Subprotocols = []string{p}
+	}
+	conn, err := websocket.Accept(w, r, opts)
+	if err != nil {
+		s.debug(r.Context(), "WebSocketの確立に失敗: %v", err)
+		return
+	}
+	defer conn.CloseNow()
+
+	// クライアントからのメッセージは受け付けず、切断のみを検知する
+	ctx := conn.CloseRead(r.Context())
+	if err := s.streamEvents(ctx, conn, name, st, types); err != nil {
+		s.debug(ctx, "WebSocket %s を切断: %v", name, err)
+		return
+	}
+	conn.Close(websocket.StatusNormalClosure, "")
+}
+
+// streamEvents 現在の状態を送信した後、状態変化を送信し続ける
+func (s *server) streamEvents(ctx context.Context, conn *websocket.Conn, name string, st state.Switcher, types []state.EventType) error {
+	events := make(chan state.Event, eventBuffer)
+	overflow := make(chan struct{})
+	unsubscribe := st.Subscribe(func(ev state.Event) {
+		select {
+		case events <- ev:
+		default:
+			// 送信が追いつかない場合は接続を閉じ、再接続時のsnapshotで同期させる
+			select {
+			case <-overflow:
+			default:
+				close(overflow)
+			}
+		}
+	}, types...)
+	defer unsubscribe()
+
+	if err := writeEvent(ctx, conn, eventMessage{
+		Type:     "snapshot",
+		Switcher: name,
+		Time:     time.Now(),
+		Data:     st.Snapshot(),
+	}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-overflow:
+			conn.Close(websocket.StatusPolicyViolation, "event buffer overflow")
+			return xerrors.New("送信が追いつかないため切断しました")
+		case ev := <-events:
+			if err := writeEvent(ctx, conn, eventMessage{
+				Type:     string(ev.Type()),
+				Switcher: name,
+				Time:     time.Now(),
+				Data:     ev,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeEvent(ctx context.Context, conn *websocket.Conn, msg eventMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, eventWriteTimeout)
+	defer cancel()
+	if err := wsjson.Write(ctx, conn, msg); err != nil {
+		return xerrors.Errorf("メッセージの送信に失敗: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+func TestEventsOrigin(t *testing.T) {
+	s, err := NewServer(Config{
+		Address:        "127.0.0.1:0",
+		Token:          "secret",
+		AllowedOrigins: []string{"localhost:3000"},
+		Controller:     &fakeController{},
+	})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer s.Close()
+	ts := httptest.NewServer(s.(*server).httpServer.Handler)
+	defer ts.Close()
+	url := "ws" + ts.URL[len("http"):] + "/switchers/main/events"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 許可されていないOriginからは購読できない
+	_, resp, err := websocket.Dial(ctx, url, &websocket.DialOptions{
+		HTTPHeader:   http.Header{"Origin": {"https://evil.example.com"}},
+		Subprotocols: []string{"token.secret"},
+	})
+	if err == nil {
+		t.Fatal("許可されていないOriginから接続できました")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("resp = %v", resp)
+	}
+
+	// ブラウザと同様にOriginとサブプロトコルのトークンで接続する
+	conn, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{
+		HTTPHeader:   http.Header{"Origin": {"http://localhost:3000"}},
+		Subprotocols: []string{"token.secret"},
+	})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.CloseNow()
+	if conn.Subprotocol() != "token.secret" {
+		t.Errorf("Subprotocol = %q", conn.Subprotocol())
+	}
+	var msg eventMessage
+	if err := wsjson.Read(ctx, conn, &msg); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if msg.Type != "snapshot" || msg.Switcher != "main" {
+		t.Errorf("msg = %+v", msg)
+	}
+}
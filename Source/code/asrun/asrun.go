@@ -0,0 +1,176 @@
+// Package asrun オンエアに関わる状態変化(PGMの変化)をJSON Lines形式のAs-Runログに記録する
+//
+// 各エントリーには操作の発生元を記録する。このプラグインから操作を送信した直後の変化は
+// 送信元(deck、api、osc)とし、それ以外はスイッチャー本体や他の機器からの操作(elsewhere)とする。
+//
+// タイムコードはスイッチャーから受信したものではなく、ホストの時計から生成したものであることをtimecodeSourceに記録する。
+//
+// ATEMクライアントはトランジションやキーヤー/DSKの状態を通知しないため、これらの変化は記録できない。
+package asrun
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/rotate"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"golang.org/x/xerrors"
+)
+
+// Origin 操作の発生元
+type Origin string
+
+const (
+	OriginDeck      Origin = "deck"      // Stream Deckのボタン
+	OriginAPI       Origin = "api"       // HTTP API
+	OriginOSC       Origin = "osc"       // OSC
+	OriginElsewhere Origin = "elsewhere" // スイッチャー本体や他の機器
+)
+
+const (
+	// DefaultDir 出力先ディレクトリの既定値。プラグインではプラグインのフォルダ以下に作成する
+	DefaultDir = "asrun"
+	// DefaultMaxSize 1ファイルの最大バイト数の既定値
+	DefaultMaxSize = 10 * 1024 * 1024
+
+	// commandWindow 操作の送信後、状態変化をその操作によるものとみなす時間
+	// AutoではトランジションがPGMが切り替わるまで続くため、トランジションの長さを含める
+	commandWindow = 5 * time.Second
+)
+
+// Entry As-Runログの1行
+type Entry struct {
+	Time           time.Time       `json:"time"`
+	Timecode       string          `json:"timecode,omitempty"`
+	TimecodeSource string          `json:"timecodeSource,omitempty"` // Timecodeの出所。"host-clock"はホストの時計から生成したもの
+	Switcher       string          `json:"switcher"`
+	Event          state.EventType `json:"event"`
+	Origin         Origin          `json:"origin"`
+	Data           state.Event     `json:"data"`
+}
+
+// Config 記録の設定
+type Config struct {
+	Dir     string        // 出力先ディレクトリ。空の場合はDefaultDir
+	MaxSize int64         // 1ファイルの最大バイト数。0の場合はDefaultMaxSize
+	MaxAge  time.Duration // これより古いファイルを削除する。0の場合は削除しない
+}
+
+// Recorder As-Runログの記録
+type Recorder interface {
+	// Command プラグインからスイッチャーへの操作の送信を記録し、直後の状態変化の発生元とする
+	Command(switcher string, origin Origin)
+	// Record 状態変化を記録する。オンエアに関わらない変化は無視する
+	// timecodeSourceはtimecodeの出所で、timecodeが空の場合は記録しない
+	Record(switcher string, ev state.Event, timecode, timecodeSource string) error
+	// Path 現在書き込んでいるファイルのパス
+	Path() string
+	Close() error
+}
+
+// NewRecorder Recorderを初期化し、ログファイルを作成する
+func NewRecorder(cfg Config) (Recorder, error) {
+	if cfg.Dir == "" {
+		cfg.Dir = DefaultDir
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultMaxSize
+	}
+	w, err := rotate.NewWriter(rotate.Config{
+		Dir:     cfg.Dir,
+		Name:    "asrun",
+		Ext:     ".jsonl",
+		MaxSize: cfg.MaxSize,
+		Daily:   true,
+		MaxAge:  cfg.MaxAge,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("As-Runログの作成に失敗: %w", err)
+	}
+	return &recorder{
+		w:         w,
+		switchers: map[string]*switcherState{},
+	}, nil
+}
+
+type recorder struct {
+	w rotate.Writer
+
+	mu        sync.Mutex // 以下を保護する
+	switchers map[string]*switcherState
+}
+
+// switcherState 発生元の判定に利用するスイッチャーごとの状態
+type switcherState struct {
+	origin    Origin    // 最後に送信した操作の発生元。状態変化に紐付けた後は空にする
+	commandAt time.Time // 最後に操作を送信した時刻
+}
+
+func (r *recorder) switcher(name string) *switcherState {
+	s, ok := r.switchers[name]
+	if !ok {
+		s = &switcherState{}
+		r.switchers[name] = s
+	}
+	return s
+}
+
+func (r *recorder) Command(switcher string, origin Origin) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.switcher(switcher)
+	s.origin = origin
+	s.commandAt = time.Now()
+}
+
+// originOf 直前に送信した操作があればその発生元を返す
+// 1つの操作を複数の状態変化の発生元としないよう、紐付けた操作は破棄する
+func (s *switcherState) originOf(now time.Time) Origin {
+	origin := s.origin
+	s.origin = ""
+	if origin != "" && now.Sub(s.commandAt) <= commandWindow {
+		return origin
+	}
+	return OriginElsewhere
+}
+
+func (r *recorder) Record(switcher string, ev state.Event, timecode, timecodeSource string) error {
+	now := time.Now()
+
+	if _, ok := ev.(state.ProgramChanged); !ok {
+		return nil
+	}
+
+	r.mu.Lock()
+	origin := r.switcher(switcher).originOf(now)
+	r.mu.Unlock()
+
+	entry := Entry{
+		Time:     now,
+		Switcher: switcher,
+		Event:    ev.Type(),
+		Origin:   origin,
+		Data:     ev,
+	}
+	if timecode != "" {
+		entry.Timecode = timecode
+		entry.TimecodeSource = timecodeSource
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return xerrors.Errorf("As-Runログのマーシャルに失敗: %w", err)
+	}
+	if _, err := r.w.Write(append(b, '\n')); err != nil {
+		return xerrors.Errorf("As-Runログの書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+func (r *recorder) Path() string {
+	return r.w.Path()
+}
+
+func (r *recorder) Close() error {
+	return r.w.Close()
+}
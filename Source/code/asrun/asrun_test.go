@@ -0,0 +1,65 @@
+package asrun
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+)
+
+// readEntries ログファイルのエントリーを読み出す
+func readEntries(t *testing.T, path string) []map[string]any {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+	var entries []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestRecorder(t *testing.T) {
+	r, err := NewRecorder(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	defer r.Close()
+
+	r.Command("10.0.0.1", OriginDeck)
+	// PGM以外の変化は記録せず、操作の発生元も消費しない
+	if err := r.Record("10.0.0.1", state.PreviewChanged{Input: 2}, "", ""); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := r.Record("10.0.0.1", state.ProgramChanged{Input: 2, Previous: 1}, "10:00:00:00", "host-clock"); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	// 操作に紐付いた後の変化はスイッチャー本体からの操作とみなす
+	if err := r.Record("10.0.0.1", state.ProgramChanged{Input: 3, Previous: 2}, "", "host-clock"); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	entries := readEntries(t, r.Path())
+	if len(entries) != 2 {
+		t.Fatalf("エントリー数 = %d, want 2", len(entries))
+	}
+	if entries[0]["origin"] != string(OriginDeck) || entries[0]["timecode"] != "10:00:00:00" || entries[0]["timecodeSource"] != "host-clock" {
+		t.Errorf("entries[0] = %v", entries[0])
+	}
+	if entries[1]["origin"] != string(OriginElsewhere) {
+		t.Errorf("entries[1] = %v", entries[1])
+	}
+	if _, ok := entries[1]["timecodeSource"]; ok {
+		t.Errorf("タイムコードなしで出所を記録しました: %v", entries[1])
+	}
+}
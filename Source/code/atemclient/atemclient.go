@@ -0,0 +1,519 @@
+package atemclient
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/FlowingSPDG/go-atem"
+	"golang.org/x/xerrors"
+)
+
+// ErrNotConnected スイッチャーとの接続が確立していない
+var ErrNotConnected = xerrors.New("スイッチャーに接続していません")
+
+const (
+	// EventConnected ATEMに接続した
+	EventConnected = "connected"
+	// EventClosed ATEMとの接続が閉じられた
+	EventClosed = "closed"
+	// EventPreviewChanged PVWが変化した
+	EventPreviewChanged = "PrvI.change"
+	// EventProgramChanged PGMが変化した
+	EventProgramChanged = "PrgI.change"
+	// EventInputChanged 入力ソースの名前が変化した
+	EventInputChanged = "InPr.change"
+)
+
+// InputProperties 入力ソースの名前
+type InputProperties struct {
+	Input     uint16
+	LongName  string
+	ShortName string
+}
+
+// Stats スイッチャーから受信した製品情報
+type Stats struct {
+	ProductName     string // スイッチャーの製品名
+	ProtocolVersion string // "2.30"の形式のプロトコルバージョン
+}
+
+// Client スイッチャーを操作するクライアント
+type Client interface {
+	IP() string
+	Connect() error
+	Close()
+	// On イベントのコールバックを登録する
+	// コールバックは受信を処理するゴルーチンで順番に呼び出されるため、ブロックしないこと
+	On(event string, callback func())
+
+	PerformCut(meIndex uint8) error
+	PerformAuto(meIndex uint8) error
+	// PerformFadeToBlack FTBを切り替える
+	PerformFadeToBlack(meIndex uint8) error
+	SetProgramInput(input atem.VideoInputType, meIndex uint8) error
+	SetPreviewInput(input atem.VideoInputType, meIndex uint8) error
+	// SetDSKOnAir DSKのOnAirを切り替える。indexは0始まり
+	SetDSKOnAir(index uint8, onAir bool) error
+
+	// ProgramInput 現在のPGMを取得する。M/Eの状態が不明な場合はfalseを返す
+	ProgramInput(meIndex uint8) (uint16, bool)
+	// PreviewInput 現在のPVWを取得する。M/Eの状態が不明な場合はfalseを返す
+	PreviewInput(meIndex uint8) (uint16, bool)
+	// Inputs 受信済みの入力ソースの名前を取得する
+	Inputs() []InputProperties
+	// Timecode ホストの時計から生成した現在のタイムコード(TimecodeSourceHostClock)を"HH:MM:SS:FF"の形式で取得する
+	// fpsはタイムコードのフレームレートで、スイッチャーの映像フォーマットから求める
+	// スイッチャーから受信したタイムコードではない。映像フォーマットを受信していない場合はfalseを返す
+	Timecode() (timecode string, fps int, ok bool)
+	// Stats スイッチャーの製品情報を取得する。まだ受信していない場合はfalseを返す
+	Stats() (Stats, bool)
+}
+
+// TimecodeSourceHostClock Client.Timecodeのタイムコードの出所。ホストの時計から生成したことを示す
+const TimecodeSourceHostClock = "host-clock"
+
+// Factory Clientを生成する
+// ipは"address"または"address:port"の形式
+type Factory func(ip string, debug bool) Client
+
+// DefaultPort ATEMの標準ポート
+const DefaultPort = "9910"
+
+const (
+	// connectTimeout 接続要求への応答を待つ時間
+	connectTimeout = time.Second
+	// receiveTimeout スイッチャーは定期的にキープアライブを送るため、この間に受信しない場合は切断とみなす
+	receiveTimeout = 2 * time.Second
+	// resendInterval 確認応答のないパケットを再送するまでの時間
+	resendInterval = 200 * time.Millisecond
+	tickInterval   = 50 * time.Millisecond
+)
+
+// NewATEMClient ATEMのUDP制御プロトコルで接続するClientを初期化する
+// ポートを省略した場合はDefaultPortに接続する
+func NewATEMClient(ip string, debug bool) Client {
+	address := ip
+	if _, _, err := net.SplitHostPort(ip); err != nil {
+		address = net.JoinHostPort(ip, DefaultPort)
+	}
+	return &atemClient{
+		ip:        ip,
+		address:   address,
+		debug:     debug,
+		listeners: map[string][]func(){},
+	}
+}
+
+type inFlightPacket struct {
+	id     uint16
+	data   []byte
+	sentAt time.Time
+}
+
+// atemClient ATEMのUDP制御プロトコルのクライアント
+// 受信した状態はmuで保護し、コールバックは受信を処理するゴルーチンで順番に呼び出す
+type atemClient struct {
+	ip      string
+	address string
+	debug   bool
+
+	listenersMu sync.Mutex
+	listeners   map[string][]func()
+
+	mu                 sync.Mutex // 以下の接続と状態を保護する
+	conn               *net.UDPConn
+	sessionID          uint16
+	nextPacketID       uint16
+	lastRemotePacketID uint16
+	inFlight           []inFlightPacket
+	initialized        bool
+	program            map[uint8]uint16
+	preview            map[uint8]uint16
+	inputs             map[uint16]InputProperties
+	videoMode          *atem.VideoMode
+	stats              Stats
+}
+
+func (c *atemClient) IP() string {
+	return c.ip
+}
+
+// Connect スイッチャーに接続し、接続が切れるかCloseされるまでブロックする
+func (c *atemClient) Connect() error {
+	addr, err := net.ResolveUDPAddr("udp", c.address)
+	if err != nil {
+		return xerrors.Errorf("アドレスの解決に失敗: %w", err)
+	}
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return xerrors.Errorf("UDPの接続に失敗: %w", err)
+	}
+
+	c.mu.Lock()
+	if c.conn != nil {
+		c.mu.Unlock()
+		conn.Close()
+		return xerrors.Errorf("%s には既に接続しています", c.address)
+	}
+	c.conn = conn
+	c.sessionID = helloSessionID
+	c.nextPacketID = 1
+	c.lastRemotePacketID = 0
+	c.inFlight = nil
+	c.initialized = false
+	c.program = map[uint8]uint16{}
+	c.preview = map[uint8]uint16{}
+	c.inputs = map[uint16]InputProperties{}
+	c.videoMode = nil
+	c.stats = Stats{}
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		initialized := c.initialized
+		c.conn = nil
+		c.initialized = false
+		c.mu.Unlock()
+		conn.Close()
+		if initialized {
+			c.emit(EventClosed)
+		}
+	}()
+
+	if err := c.handshake(conn); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go c.resendLoop(done)
+
+	buf := make([]byte, 2048)
+	for {
+		conn.SetReadDeadline(time.Now().Add(receiveTimeout))
+		n, err := conn.Read(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return xerrors.Errorf("パケットの受信に失敗: %w", err)
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		pkt, err := parsePacket(data)
+		if err != nil {
+			c.debugf("不正なパケットを破棄: %v", err)
+			continue
+		}
+		c.handlePacket(pkt)
+	}
+}
+
+// handshake 接続要求を送り、スイッチャーが受け付けるのを待つ
+func (c *atemClient) handshake(conn *net.UDPConn) error {
+	hello := make([]byte, 8)
+	hello[0] = helloConnect
+	if _, err := conn.Write((&packet{flags: flagNewSessionID, sessionID: helloSessionID, payload: hello}).marshal()); err != nil {
+		return xerrors.Errorf("接続要求の送信に失敗: %w", err)
+	}
+
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(connectTimeout))
+	n, err := conn.Read(buf)
+	if err != nil {
+		return xerrors.Errorf("接続要求への応答がありません: %w", err)
+	}
+	pkt, err := parsePacket(buf[:n])
+	if err != nil {
+		return xerrors.Errorf("接続要求への応答が不正です: %w", err)
+	}
+	if !pkt.has(flagNewSessionID) || len(pkt.payload) < 1 || pkt.payload[0] != helloAccepted {
+		return xerrors.Errorf("%s が接続を拒否しました", c.address)
+	}
+	if _, err := conn.Write((&packet{flags: flagAckReply, sessionID: pkt.sessionID}).marshal()); err != nil {
+		return xerrors.Errorf("確認応答の送信に失敗: %w", err)
+	}
+	return nil
+}
+
+// handlePacket 受信したパケットを状態に反映し、変化したイベントのコールバックを呼び出す
+func (c *atemClient) handlePacket(pkt *packet) {
+	var events []string
+	c.mu.Lock()
+	c.sessionID = pkt.sessionID
+	if pkt.has(flagAckReply) {
+		c.ack(pkt.ackPacketID)
+	}
+	if pkt.has(flagRetransmitRequest) {
+		c.resendFrom(pkt.retransmitFromID)
+	}
+	if pkt.has(flagAckRequest) {
+		// 重複・順序違いのパケットは読み飛ばし、最後に受け取ったパケットを確認応答して欠落分を再送させる
+		if pkt.packetID == (c.lastRemotePacketID+1)%maxPacketID {
+			c.lastRemotePacketID = pkt.packetID
+			commands, err := parseCommands(pkt.payload)
+			if err != nil {
+				c.debugf("コマンドの解析に失敗: %v", err)
+			}
+			for _, cmd := range commands {
+				if event := c.apply(cmd); event != "" && !slices.Contains(events, event) {
+					events = append(events, event)
+				}
+			}
+		}
+		c.write(&packet{flags: flagAckReply, sessionID: c.sessionID, ackPacketID: c.lastRemotePacketID})
+	}
+	c.mu.Unlock()
+
+	for _, event := range events {
+		c.emit(event)
+	}
+}
+
+// apply コマンドを状態に反映し、通知するイベントを返す。c.muを保持して呼び出すこと
+func (c *atemClient) apply(cmd command) string {
+	c.debugf("受信: %s %x", cmd.name, cmd.data)
+	switch cmd.name {
+	case "_ver":
+		if len(cmd.data) >= 4 {
+			c.stats.ProtocolVersion = fmt.Sprintf("%d.%d", binary.BigEndian.Uint16(cmd.data[0:2]), binary.BigEndian.Uint16(cmd.data[2:4]))
+		}
+	case "_pin":
+		c.stats.ProductName = nullTerminated(cmd.data[:min(len(cmd.data), 40)])
+	case "VidM":
+		if len(cmd.data) >= 1 {
+			c.videoMode = atem.NewVideoModeByIndex(cmd.data[0])
+		}
+	case "InPr":
+		if len(cmd.data) >= 26 {
+			input := binary.BigEndian.Uint16(cmd.data[0:2])
+			c.inputs[input] = InputProperties{
+				Input:     input,
+				LongName:  nullTerminated(cmd.data[2:22]),
+				ShortName: nullTerminated(cmd.data[22:26]),
+			}
+			return EventInputChanged
+		}
+	case "PrgI":
+		if len(cmd.data) >= 4 {
+			c.program[cmd.data[0]] = binary.BigEndian.Uint16(cmd.data[2:4])
+			return EventProgramChanged
+		}
+	case "PrvI":
+		if len(cmd.data) >= 4 {
+			c.preview[cmd.data[0]] = binary.BigEndian.Uint16(cmd.data[2:4])
+			return EventPreviewChanged
+		}
+	case "InCm":
+		// 初期状態を受信し終えた
+		if !c.initialized {
+			c.initialized = true
+			return EventConnected
+		}
+	}
+	return ""
+}
+
+// ack ackPacketID以前の送信済みパケットを確認済みにする。c.muを保持して呼び出すこと
+func (c *atemClient) ack(ackPacketID uint16) {
+	remaining := c.inFlight[:0]
+	for _, p := range c.inFlight {
+		if p.id == ackPacketID || packetIDBefore(p.id, ackPacketID) {
+			continue
+		}
+		remaining = append(remaining, p)
+	}
+	c.inFlight = remaining
+}
+
+// resendFrom fromID以降の未確認パケットを再送する。c.muを保持して呼び出すこと
+func (c *atemClient) resendFrom(fromID uint16) {
+	now := time.Now()
+	for i, p := range c.inFlight {
+		if p.id != fromID && packetIDBefore(p.id, fromID) {
+			continue
+		}
+		c.write(&packet{flags: flagAckRequest | flagIsRetransmit, sessionID: c.sessionID, packetID: p.id, payload: p.data})
+		c.inFlight[i].sentAt = now
+	}
+}
+
+// resendLoop 確認応答のないパケットを再送する
+func (c *atemClient) resendLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			for i, p := range c.inFlight {
+				if now.Sub(p.sentAt) > resendInterval {
+					c.write(&packet{flags: flagAckRequest | flagIsRetransmit, sessionID: c.sessionID, packetID: p.id, payload: p.data})
+					c.inFlight[i].sentAt = now
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// write パケットを送信する。c.muを保持して呼び出すこと
+func (c *atemClient) write(pkt *packet) error {
+	if c.conn == nil {
+		return ErrNotConnected
+	}
+	if _, err := c.conn.Write(pkt.marshal()); err != nil {
+		return xerrors.Errorf("パケットの送信に失敗: %w", err)
+	}
+	return nil
+}
+
+// send 確認応答を要求するパケットでコマンドを送信する。応答がない場合はresendLoopが再送する
+func (c *atemClient) send(cmd command) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.initialized {
+		return ErrNotConnected
+	}
+
+	id := c.nextPacketID
+	c.nextPacketID = (c.nextPacketID + 1) % maxPacketID
+	payload := cmd.marshal()
+	c.inFlight = append(c.inFlight, inFlightPacket{id: id, data: payload, sentAt: time.Now()})
+	if err := c.write(&packet{flags: flagAckRequest, sessionID: c.sessionID, packetID: id, payload: payload}); err != nil {
+		return xerrors.Errorf("%s の送信に失敗: %w", cmd.name, err)
+	}
+	return nil
+}
+
+// emit イベントのコールバックを呼び出す
+func (c *atemClient) emit(event string) {
+	c.listenersMu.Lock()
+	callbacks := append([]func(){}, c.listeners[event]...)
+	c.listenersMu.Unlock()
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+func (c *atemClient) debugf(format string, args ...any) {
+	if c.debug {
+		log.Printf("[atemclient %s] "+format, append([]any{c.address}, args...)...)
+	}
+}
+
+// Close 接続を閉じる。Connectは切断を検知して戻る
+func (c *atemClient) Close() {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+func (c *atemClient) On(event string, callback func()) {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+	c.listeners[event] = append(c.listeners[event], callback)
+}
+
+func (c *atemClient) PerformCut(meIndex uint8) error {
+	return c.send(command{name: "DCut", data: []byte{meIndex, 0, 0, 0}})
+}
+
+func (c *atemClient) PerformAuto(meIndex uint8) error {
+	return c.send(command{name: "DAut", data: []byte{meIndex, 0, 0, 0}})
+}
+
+func (c *atemClient) PerformFadeToBlack(meIndex uint8) error {
+	return c.send(command{name: "FtbA", data: []byte{meIndex, 0, 0, 0}})
+}
+
+func (c *atemClient) SetProgramInput(input atem.VideoInputType, meIndex uint8) error {
+	return c.send(inputCommand("CPgI", meIndex, uint16(input)))
+}
+
+func (c *atemClient) SetPreviewInput(input atem.VideoInputType, meIndex uint8) error {
+	return c.send(inputCommand("CPvI", meIndex, uint16(input)))
+}
+
+func (c *atemClient) SetDSKOnAir(index uint8, onAir bool) error {
+	var onAirByte uint8
+	if onAir {
+		onAirByte = 1
+	}
+	return c.send(command{name: "CDsL", data: []byte{index, onAirByte, 0, 0}})
+}
+
+func (c *atemClient) ProgramInput(meIndex uint8) (uint16, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	input, ok := c.program[meIndex]
+	return input, ok
+}
+
+func (c *atemClient) PreviewInput(meIndex uint8) (uint16, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	input, ok := c.preview[meIndex]
+	return input, ok
+}
+
+func (c *atemClient) Inputs() []InputProperties {
+	c.mu.Lock()
+	inputs := make([]InputProperties, 0, len(c.inputs))
+	for _, in := range c.inputs {
+		inputs = append(inputs, in)
+	}
+	c.mu.Unlock()
+	sort.Slice(inputs, func(i, j int) bool {
+		return inputs[i].Input < inputs[j].Input
+	})
+	return inputs
+}
+
+// Timecode ATEMはタイムコードを送信しないため、ホストの現在時刻を映像フォーマットのフレームレートで表す
+func (c *atemClient) Timecode() (string, int, bool) {
+	c.mu.Lock()
+	mode := c.videoMode
+	c.mu.Unlock()
+	if mode == nil || mode.FrameRate <= 0 {
+		return "", 0, false
+	}
+	fps := timecodeFPS(mode)
+	return timeOfDayTimecode(time.Now(), fps), fps, true
+}
+
+// timecodeFPS 映像フォーマットのタイムコードのフレームレート
+// インターレースのフレームレートはフィールドレートのため、フレーム番号は半分の値で数える
+func timecodeFPS(mode *atem.VideoMode) int {
+	fps := float64(mode.FrameRate)
+	if mode.ScanType == atem.InterlaceScanType {
+		fps /= 2
+	}
+	return int(math.Round(fps))
+}
+
+// timeOfDayTimecode 時刻tをfpsの"HH:MM:SS:FF"の形式のタイムコードにする
+func timeOfDayTimecode(t time.Time, fps int) string {
+	frame := int(float64(t.Nanosecond()) / float64(time.Second) * float64(fps))
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", t.Hour(), t.Minute(), t.Second(), frame)
+}
+
+// Stats 初期状態で受信した_pinと_verから製品情報を返す
+func (c *atemClient) Stats() (Stats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats, c.stats.ProductName != ""
+}
@@ -0,0 +1,139 @@
+package atemclient
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"golang.org/x/xerrors"
+)
+
+// ATEM UDPプロトコルのパケットとコマンド
+// atemsimはクライアントとは独立にプロトコルを実装し、互いの誤りを検出できるようにしている
+
+// packetFlag ATEMパケットヘッダのフラグ
+type packetFlag uint8
+
+const (
+	flagAckRequest        packetFlag = 0x01
+	flagNewSessionID      packetFlag = 0x02
+	flagIsRetransmit      packetFlag = 0x04
+	flagRetransmitRequest packetFlag = 0x08
+	flagAckReply          packetFlag = 0x10
+)
+
+const (
+	headerLength = 12
+	// maxPacketID パケットIDは15bitで折り返す
+	maxPacketID = 1 << 15
+	// helloSessionID 接続要求に使う仮のセッションID。接続後はスイッチャーが割り当てたIDを使う
+	helloSessionID = 0x53ab
+)
+
+const (
+	helloConnect  byte = 0x01
+	helloAccepted byte = 0x02
+)
+
+// packet ATEM UDPプロトコルのパケット
+type packet struct {
+	flags            packetFlag
+	sessionID        uint16
+	ackPacketID      uint16 // flagAckReplyの時、確認応答するパケットID
+	retransmitFromID uint16 // flagRetransmitRequestの時、再送を要求されたパケットID
+	packetID         uint16 // flagAckRequestの時、このパケットのID
+	payload          []byte
+}
+
+func (p *packet) has(f packetFlag) bool {
+	return p.flags&f != 0
+}
+
+func parsePacket(b []byte) (*packet, error) {
+	if len(b) < headerLength {
+		return nil, xerrors.Errorf("パケットが短すぎます: %d bytes", len(b))
+	}
+	length := int(binary.BigEndian.Uint16(b[0:2]) & 0x07ff)
+	if length != len(b) {
+		return nil, xerrors.Errorf("パケット長が一致しません: header:%d actual:%d", length, len(b))
+	}
+
+	return &packet{
+		flags:            packetFlag(b[0] >> 3),
+		sessionID:        binary.BigEndian.Uint16(b[2:4]),
+		ackPacketID:      binary.BigEndian.Uint16(b[4:6]),
+		retransmitFromID: binary.BigEndian.Uint16(b[6:8]),
+		packetID:         binary.BigEndian.Uint16(b[10:12]),
+		payload:          b[headerLength:],
+	}, nil
+}
+
+func (p *packet) marshal() []byte {
+	length := headerLength + len(p.payload)
+	b := make([]byte, length)
+	binary.BigEndian.PutUint16(b[0:2], uint16(p.flags)<<11|uint16(length)&0x07ff)
+	binary.BigEndian.PutUint16(b[2:4], p.sessionID)
+	binary.BigEndian.PutUint16(b[4:6], p.ackPacketID)
+	binary.BigEndian.PutUint16(b[6:8], p.retransmitFromID)
+	binary.BigEndian.PutUint16(b[10:12], p.packetID)
+	copy(b[headerLength:], p.payload)
+	return b
+}
+
+// packetIDBefore aがbより前に送られたパケットIDかどうか
+func packetIDBefore(a, b uint16) bool {
+	diff := (int(b) - int(a) + maxPacketID) % maxPacketID
+	return diff != 0 && diff < maxPacketID/2
+}
+
+const commandHeaderLength = 8
+
+// command ATEMパケットに格納されるコマンド
+type command struct {
+	name string
+	data []byte
+}
+
+func (c command) marshal() []byte {
+	length := commandHeaderLength + len(c.data)
+	b := make([]byte, length)
+	binary.BigEndian.PutUint16(b[0:2], uint16(length))
+	copy(b[4:8], c.name)
+	copy(b[8:], c.data)
+	return b
+}
+
+// parseCommands パケットのペイロードをコマンドに分割する
+func parseCommands(payload []byte) ([]command, error) {
+	var commands []command
+	for len(payload) > 0 {
+		if len(payload) < commandHeaderLength {
+			return nil, xerrors.Errorf("コマンドヘッダが短すぎます: %d bytes", len(payload))
+		}
+		length := int(binary.BigEndian.Uint16(payload[0:2]))
+		if length < commandHeaderLength || length > len(payload) {
+			return nil, xerrors.Errorf("コマンド長が不正です: %d", length)
+		}
+		commands = append(commands, command{
+			name: string(payload[4:8]),
+			data: payload[commandHeaderLength:length],
+		})
+		payload = payload[length:]
+	}
+	return commands, nil
+}
+
+// inputCommand M/Eと入力番号を指定するコマンド
+func inputCommand(name string, meIndex uint8, input uint16) command {
+	data := make([]byte, 4)
+	data[0] = meIndex
+	binary.BigEndian.PutUint16(data[2:4], input)
+	return command{name: name, data: data}
+}
+
+// nullTerminated 固定長のnull終端文字列を読み出す
+func nullTerminated(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
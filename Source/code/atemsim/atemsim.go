@@ -0,0 +1,438 @@
+package atemsim
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/logger"
+	"github.com/puzpuzpuz/xsync/v3"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// DefaultAddress ATEMの標準ポート
+	DefaultAddress = "127.0.0.1:9910"
+
+	tickInterval   = 20 * time.Millisecond
+	resendInterval = 200 * time.Millisecond
+	pingInterval   = 500 * time.Millisecond
+	sessionTimeout = 5 * time.Second
+)
+
+// Config シミュレーターの設定
+type Config struct {
+	Model    Model
+	Address  string        // 待ち受けアドレス。空の場合はDefaultAddress
+	DropRate float64       // 送受信するパケットを破棄する確率(0.0-1.0)
+	Logger   logger.Logger // nilの場合はログを出力しない
+}
+
+// Server ATEMのUDP制御プロトコルを話すシミュレーター
+type Server interface {
+	Addr() net.Addr
+	// Run ctxが終了するまでパケットを処理する
+	Run(ctx context.Context) error
+	Close() error
+
+	// SetDropRate パケットロス率を変更する
+	SetDropRate(rate float64)
+	// Disconnect 全てのセッションを破棄し、クライアントにタイムアウトさせる
+	Disconnect()
+	// Sessions 確立済みのセッション数
+	Sessions() int
+
+	// 以下はスイッチャー本体のパネルから操作された場合を模倣する
+	SetProgramInput(meIndex uint8, input uint16) error
+	SetPreviewInput(meIndex uint8, input uint16) error
+	Cut(meIndex uint8) error
+	Auto(meIndex uint8) error
+}
+
+// NewServer シミュレーターを初期化し、UDPポートをbindする
+func NewServer(cfg Config) (Server, error) {
+	if cfg.Model.Name == "" {
+		cfg.Model = ModelMini
+	}
+	if cfg.Address == "" {
+		cfg.Address = DefaultAddress
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", cfg.Address)
+	if err != nil {
+		return nil, xerrors.Errorf("アドレスの解決に失敗: %w", err)
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, xerrors.Errorf("UDPの待ち受けに失敗: %w", err)
+	}
+
+	s := &server{
+		model:    cfg.Model,
+		conn:     conn,
+		logger:   cfg.Logger,
+		dropRate: cfg.DropRate,
+		sessions: xsync.NewMapOf[string, *session](),
+		mes:      make([]meState, cfg.Model.MixEffects),
+		dsks:     make([]dskState, cfg.Model.DSKs),
+		aux:      make([]uint16, cfg.Model.Aux),
+		closed:   make(chan struct{}),
+	}
+	for i := range s.mes {
+		s.mes[i] = meState{program: 1, preview: 2, keyers: make([]bool, cfg.Model.KeyersPerME)}
+	}
+	return s, nil
+}
+
+type meState struct {
+	program         uint16
+	preview         uint16
+	inTransition    bool
+	position        uint16
+	ftbFullyBlack   bool
+	ftbInTransition bool
+	keyers          []bool
+}
+
+type dskState struct {
+	onAir        bool
+	inTransition bool
+}
+
+type inFlightPacket struct {
+	id     uint16
+	data   []byte
+	sentAt time.Time
+}
+
+type session struct {
+	addr *net.UDPAddr
+
+	mu                 sync.Mutex
+	id                 uint16
+	established        bool
+	nextPacketID       uint16
+	lastRemotePacketID uint16
+	lastReceived       time.Time
+	lastSent           time.Time
+	inFlight           []inFlightPacket
+}
+
+type server struct {
+	model  Model
+	conn   *net.UDPConn
+	logger logger.Logger
+
+	dropMu   sync.RWMutex
+	dropRate float64
+
+	sessions      *xsync.MapOf[string, *session]
+	nextSessionID uint16
+
+	mu   sync.Mutex // 以下のスイッチャー状態を保護する
+	mes  []meState
+	dsks []dskState
+	aux  []uint16
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (s *server) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+func (s *server) Run(ctx context.Context) error {
+	go s.tickLoop(ctx)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case <-s.closed:
+		}
+	}()
+
+	buf := make([]byte, 2048)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return xerrors.Errorf("パケットの受信に失敗: %w", err)
+		}
+		if s.shouldDrop() {
+			continue
+		}
+
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		pkt, err := parsePacket(data)
+		if err != nil {
+			s.debug(ctx, "不正なパケットを破棄: %v", err)
+			continue
+		}
+		s.handlePacket(ctx, addr, pkt)
+	}
+}
+
+func (s *server) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		err = s.conn.Close()
+	})
+	return err
+}
+
+func (s *server) SetDropRate(rate float64) {
+	s.dropMu.Lock()
+	defer s.dropMu.Unlock()
+	s.dropRate = rate
+}
+
+func (s *server) shouldDrop() bool {
+	s.dropMu.RLock()
+	defer s.dropMu.RUnlock()
+	return s.dropRate > 0 && rand.Float64() < s.dropRate
+}
+
+func (s *server) Disconnect() {
+	s.sessions.Clear()
+}
+
+func (s *server) Sessions() int {
+	count := 0
+	s.sessions.Range(func(_ string, sess *session) bool {
+		sess.mu.Lock()
+		defer sess.mu.Unlock()
+		if sess.established {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+func (s *server) debug(ctx context.Context, format string, args ...any) {
+	if s.logger == nil {
+		return
+	}
+	s.logger.Debug(ctx, "[atemsim] "+format, args...)
+}
+
+// handlePacket クライアントから受信したパケットを処理する
+func (s *server) handlePacket(ctx context.Context, addr *net.UDPAddr, pkt *packet) {
+	if pkt.has(flagNewSessionID) {
+		s.handleHello(ctx, addr, pkt)
+		return
+	}
+
+	sess, ok := s.sessions.Load(addr.String())
+	if !ok {
+		// 実機と同様に、未知のセッションからのパケットは無視する
+		return
+	}
+
+	sess.mu.Lock()
+	sess.lastReceived = time.Now()
+
+	if pkt.has(flagAckReply) {
+		sess.ack(pkt.ackPacketID)
+		if !sess.established {
+			sess.established = true
+			sess.mu.Unlock()
+			s.debug(ctx, "セッション %04x を確立: %s", sess.id, addr)
+			s.sendInitialState(sess)
+			sess.mu.Lock()
+		}
+	}
+
+	if pkt.has(flagRetransmitRequest) {
+		s.resendFrom(sess, pkt.retransmitFromID)
+	}
+
+	var commands []command
+	if pkt.has(flagAckRequest) {
+		s.write(sess.addr, &packet{flags: flagAckReply, sessionID: sess.id, ackPacketID: pkt.packetID})
+
+		// 重複・順序違いのパケットは確認応答のみ行う
+		if pkt.packetID == (sess.lastRemotePacketID+1)%maxPacketID {
+			sess.lastRemotePacketID = pkt.packetID
+			parsed, err := parseCommands(pkt.payload)
+			if err != nil {
+				s.debug(ctx, "コマンドの解析に失敗: %v", err)
+			}
+			commands = parsed
+		}
+	}
+	sess.mu.Unlock()
+
+	for _, c := range commands {
+		s.handleCommand(ctx, c)
+	}
+}
+
+func (s *server) handleHello(ctx context.Context, addr *net.UDPAddr, pkt *packet) {
+	s.mu.Lock()
+	s.nextSessionID = (s.nextSessionID + 1) % maxPacketID
+	id := 0x8000 | s.nextSessionID
+	s.mu.Unlock()
+
+	sess := &session{
+		addr:         addr,
+		id:           id,
+		nextPacketID: 1,
+		lastReceived: time.Now(),
+	}
+	s.sessions.Store(addr.String(), sess)
+	s.debug(ctx, "Helloを受信: %s session:%04x", addr, id)
+
+	payload := make([]byte, helloLength-headerLength)
+	payload[0] = helloAccepted
+	s.write(addr, &packet{flags: flagNewSessionID, sessionID: pkt.sessionID, payload: payload})
+}
+
+// ack ackPacketID以前の送信済みパケットを確認済みにする。sess.muを保持して呼び出すこと
+func (sess *session) ack(ackPacketID uint16) {
+	remaining := sess.inFlight[:0]
+	for _, p := range sess.inFlight {
+		if p.id == ackPacketID || packetIDBefore(p.id, ackPacketID) {
+			continue
+		}
+		remaining = append(remaining, p)
+	}
+	sess.inFlight = remaining
+}
+
+// resendFrom fromID以降の未確認パケットを再送する。sess.muを保持して呼び出すこと
+func (s *server) resendFrom(sess *session, fromID uint16) {
+	now := time.Now()
+	for i, p := range sess.inFlight {
+		if p.id != fromID && packetIDBefore(p.id, fromID) {
+			continue
+		}
+		s.write(sess.addr, &packet{flags: flagAckRequest | flagIsRetransmit, sessionID: sess.id, packetID: p.id, payload: p.data})
+		sess.inFlight[i].sentAt = now
+	}
+}
+
+// sendReliable 確認応答を要求するパケットを送信する
+func (s *server) sendReliable(sess *session, payload []byte) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	id := sess.nextPacketID
+	sess.nextPacketID = (sess.nextPacketID + 1) % maxPacketID
+	now := time.Now()
+	sess.inFlight = append(sess.inFlight, inFlightPacket{id: id, data: payload, sentAt: now})
+	sess.lastSent = now
+	s.write(sess.addr, &packet{flags: flagAckRequest, sessionID: sess.id, packetID: id, payload: payload})
+}
+
+func (s *server) write(addr *net.UDPAddr, pkt *packet) {
+	if s.shouldDrop() {
+		return
+	}
+	s.conn.WriteToUDP(pkt.marshal(), addr)
+}
+
+// tickLoop 再送、キープアライブ、タイムアウトを処理する
+func (s *server) tickLoop(ctx context.Context) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.closed:
+			return
+		case now := <-ticker.C:
+			s.sessions.Range(func(key string, sess *session) bool {
+				sess.mu.Lock()
+				if now.Sub(sess.lastReceived) > sessionTimeout {
+					sess.mu.Unlock()
+					s.debug(ctx, "セッション %04x がタイムアウト", sess.id)
+					s.sessions.Delete(key)
+					return true
+				}
+				for i, p := range sess.inFlight {
+					if now.Sub(p.sentAt) > resendInterval {
+						s.write(sess.addr, &packet{flags: flagAckRequest | flagIsRetransmit, sessionID: sess.id, packetID: p.id, payload: p.data})
+						sess.inFlight[i].sentAt = now
+					}
+				}
+				needPing := sess.established && now.Sub(sess.lastSent) > pingInterval
+				sess.mu.Unlock()
+
+				if needPing {
+					s.sendReliable(sess, nil)
+				}
+				return true
+			})
+		}
+	}
+}
+
+// sendInitialState 接続直後の状態ダンプを送信する
+func (s *server) sendInitialState(sess *session) {
+	commands := []command{
+		versionCommand(s.model.ProtocolMajor, s.model.ProtocolMinor),
+		productCommand(s.model.Name, s.model.ModelID),
+		topologyCommand(s.model),
+		videoModeCommand(s.model.videoMode()),
+	}
+	for me := uint8(0); me < s.model.MixEffects; me++ {
+		commands = append(commands, mixEffectConfigCommand(me, s.model.KeyersPerME))
+	}
+	for _, src := range s.model.sources() {
+		commands = append(commands, inputPropertiesCommand(src))
+	}
+
+	s.mu.Lock()
+	for i, me := range s.mes {
+		meIndex := uint8(i)
+		commands = append(commands,
+			programInputCommand(meIndex, me.program),
+			previewInputCommand(meIndex, me.preview),
+			transitionPositionCommand(meIndex, me.inTransition, 0, me.position),
+			fadeToBlackStateCommand(meIndex, me.ftbFullyBlack, me.ftbInTransition),
+		)
+		for k, onAir := range me.keyers {
+			commands = append(commands, keyerOnAirCommand(meIndex, uint8(k), onAir))
+		}
+	}
+	for i, dsk := range s.dsks {
+		commands = append(commands, dskStateCommand(uint8(i), dsk.onAir, dsk.inTransition))
+	}
+	for i, src := range s.aux {
+		commands = append(commands, auxSourceCommand(uint8(i), src))
+	}
+	s.mu.Unlock()
+
+	commands = append(commands, initCompleteCommand())
+	for _, payload := range packCommands(commands) {
+		s.sendReliable(sess, payload)
+	}
+}
+
+// broadcast 確立済みの全セッションにコマンドを送信する
+func (s *server) broadcast(commands ...command) {
+	payloads := packCommands(commands)
+	s.sessions.Range(func(_ string, sess *session) bool {
+		sess.mu.Lock()
+		established := sess.established
+		sess.mu.Unlock()
+		if !established {
+			return true
+		}
+		for _, payload := range payloads {
+			s.sendReliable(sess, payload)
+		}
+		return true
+	})
+}
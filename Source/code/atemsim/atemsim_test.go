@@ -0,0 +1,235 @@
+package atemsim
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/FlowingSPDG/go-atem"
+	"github.com/FlowingSPDG/std-atem/Source/code/atemclient"
+)
+
+// startServer シミュレーターを起動し、テスト終了時に停止する
+func startServer(t *testing.T, address string) Server {
+	t.Helper()
+	sim, err := NewServer(Config{Address: address})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := sim.Run(ctx); err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return sim
+}
+
+// readPacket タイムアウト付きでパケットを1つ受信する
+func readPacket(t *testing.T, conn *net.UDPConn) *packet {
+	t.Helper()
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("パケットの受信に失敗: %v", err)
+	}
+	pkt, err := parsePacket(buf[:n])
+	if err != nil {
+		t.Fatalf("parsePacket: %v", err)
+	}
+	return pkt
+}
+
+func TestHandshake(t *testing.T) {
+	sim := startServer(t, "127.0.0.1:0")
+
+	conn, err := net.DialUDP("udp", nil, sim.Addr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatalf("DialUDP: %v", err)
+	}
+	defer conn.Close()
+
+	const clientSessionID = 0x1337
+	hello := make([]byte, helloLength-headerLength)
+	hello[0] = helloConnect
+	conn.Write((&packet{flags: flagNewSessionID, sessionID: clientSessionID, payload: hello}).marshal())
+
+	reply := readPacket(t, conn)
+	if !reply.has(flagNewSessionID) || reply.sessionID != clientSessionID {
+		t.Fatalf("Helloの応答が不正です: flags:%02x session:%04x", reply.flags, reply.sessionID)
+	}
+	if len(reply.payload) == 0 || reply.payload[0] != helloAccepted {
+		t.Fatalf("接続が受け付けられませんでした: %v", reply.payload)
+	}
+	conn.Write((&packet{flags: flagAckReply, sessionID: clientSessionID}).marshal())
+
+	// 初期状態はInCmで終わる
+	received := map[string][]byte{}
+	for received["InCm"] == nil {
+		pkt := readPacket(t, conn)
+		if !pkt.has(flagAckRequest) {
+			continue
+		}
+		conn.Write((&packet{flags: flagAckReply, sessionID: pkt.sessionID, ackPacketID: pkt.packetID}).marshal())
+		commands, err := parseCommands(pkt.payload)
+		if err != nil {
+			t.Fatalf("parseCommands: %v", err)
+		}
+		for _, c := range commands {
+			received[c.name] = c.data
+		}
+	}
+
+	for _, name := range []string{"_ver", "_pin", "_top", "VidM", "InPr", "PrgI", "PrvI"} {
+		if _, ok := received[name]; !ok {
+			t.Errorf("初期状態に %s が含まれていません", name)
+		}
+	}
+	if got := binary.BigEndian.Uint16(received["PrgI"][2:4]); got != 1 {
+		t.Errorf("PGM = %d, want 1", got)
+	}
+	if got := binary.BigEndian.Uint16(received["PrvI"][2:4]); got != 2 {
+		t.Errorf("PVW = %d, want 2", got)
+	}
+	if got := sim.Sessions(); got != 1 {
+		t.Errorf("Sessions() = %d, want 1", got)
+	}
+}
+
+// waitInput PGMかPVWがwantになるまで待機する
+func waitInput(t *testing.T, changed <-chan struct{}, get func(uint8) (uint16, bool), want uint16) {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		if got, ok := get(0); ok && got == want {
+			return
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			got, _ := get(0)
+			t.Fatalf("入力が %d になりませんでした (現在 %d)", want, got)
+		}
+	}
+}
+
+// notifier コールバックを通知用のチャネルに変換する
+func notifier() (chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	return ch, func() {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// testClient シミュレーターに接続したクライアントと、イベントの通知
+type testClient struct {
+	atemclient.Client
+	program <-chan struct{}
+	preview <-chan struct{}
+}
+
+// connectClient シミュレーターに接続し、初期状態の受信を待つ
+func connectClient(t *testing.T, sim Server) testClient {
+	t.Helper()
+	client := atemclient.NewATEMClient(sim.Addr().String(), false)
+	connected, onConnected := notifier()
+	program, onProgram := notifier()
+	preview, onPreview := notifier()
+	client.On(atemclient.EventConnected, onConnected)
+	client.On(atemclient.EventProgramChanged, onProgram)
+	client.On(atemclient.EventPreviewChanged, onPreview)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.Connect()
+	}()
+	t.Cleanup(func() {
+		client.Close()
+		<-done
+	})
+	select {
+	case <-connected:
+	case <-time.After(3 * time.Second):
+		t.Fatal("シミュレーターに接続できませんでした")
+	}
+	return testClient{Client: client, program: program, preview: preview}
+}
+
+func TestProgramPreviewRoundTrip(t *testing.T) {
+	sim := startServer(t, "127.0.0.1:0")
+	client := connectClient(t, sim)
+
+	waitInput(t, client.program, client.ProgramInput, 1)
+	waitInput(t, client.preview, client.PreviewInput, 2)
+	if stats, ok := client.Stats(); !ok || stats.ProductName != ModelMini.Name {
+		t.Errorf("Stats() = %+v, %v", stats, ok)
+	}
+	if inputs := client.Inputs(); len(inputs) == 0 || inputs[0].Input != 0 {
+		t.Errorf("Inputs() = %+v", inputs)
+	}
+
+	// クライアントからの操作がシミュレーターに反映され、状態として返ってくる
+	if err := client.SetPreviewInput(atem.VideoInputType(3), 0); err != nil {
+		t.Fatalf("SetPreviewInput: %v", err)
+	}
+	waitInput(t, client.preview, client.PreviewInput, 3)
+	if err := client.SetProgramInput(atem.VideoInputType(4), 0); err != nil {
+		t.Fatalf("SetProgramInput: %v", err)
+	}
+	waitInput(t, client.program, client.ProgramInput, 4)
+
+	if err := client.PerformCut(0); err != nil {
+		t.Fatalf("PerformCut: %v", err)
+	}
+	waitInput(t, client.program, client.ProgramInput, 3)
+	waitInput(t, client.preview, client.PreviewInput, 4)
+
+	// スイッチャー本体での操作もクライアントに届く
+	if err := sim.SetProgramInput(0, 2); err != nil {
+		t.Fatalf("sim.SetProgramInput: %v", err)
+	}
+	waitInput(t, client.program, client.ProgramInput, 2)
+}
+
+func TestPacketLoss(t *testing.T) {
+	sim := startServer(t, "127.0.0.1:0")
+	client := connectClient(t, sim)
+	waitInput(t, client.program, client.ProgramInput, 1)
+
+	// 送受信の両方で失われたパケットは再送される
+	sim.SetDropRate(0.3)
+	for input := uint16(2); input <= 4; input++ {
+		if err := client.SetProgramInput(atem.VideoInputType(input), 0); err != nil {
+			t.Fatalf("SetProgramInput: %v", err)
+		}
+		waitInput(t, client.program, client.ProgramInput, input)
+	}
+	if err := sim.SetPreviewInput(0, 1); err != nil {
+		t.Fatalf("sim.SetPreviewInput: %v", err)
+	}
+	waitInput(t, client.preview, client.PreviewInput, 1)
+}
+
+func TestClientNotConnected(t *testing.T) {
+	client := atemclient.NewATEMClient("127.0.0.1:1", false)
+	if err := client.PerformCut(0); !errors.Is(err, atemclient.ErrNotConnected) {
+		t.Errorf("PerformCut = %v, want ErrNotConnected", err)
+	}
+	if _, ok := client.ProgramInput(0); ok {
+		t.Error("接続前にPGMを取得できました")
+	}
+}
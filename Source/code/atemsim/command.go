@@ -0,0 +1,167 @@
+package atemsim
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/xerrors"
+)
+
+const commandHeaderLength = 8
+
+// command ATEMパケットに格納されるコマンド
+type command struct {
+	name string
+	data []byte
+}
+
+func (c command) length() int {
+	return commandHeaderLength + len(c.data)
+}
+
+func (c command) marshal() []byte {
+	b := make([]byte, c.length())
+	binary.BigEndian.PutUint16(b[0:2], uint16(c.length()))
+	copy(b[4:8], c.name)
+	copy(b[8:], c.data)
+	return b
+}
+
+// parseCommands パケットのペイロードをコマンドに分割する
+func parseCommands(payload []byte) ([]command, error) {
+	var commands []command
+	for len(payload) > 0 {
+		if len(payload) < commandHeaderLength {
+			return nil, xerrors.Errorf("コマンドヘッダが短すぎます: %d bytes", len(payload))
+		}
+		length := int(binary.BigEndian.Uint16(payload[0:2]))
+		if length < commandHeaderLength || length > len(payload) {
+			return nil, xerrors.Errorf("コマンド長が不正です: %d", length)
+		}
+		commands = append(commands, command{
+			name: string(payload[4:8]),
+			data: payload[commandHeaderLength:length],
+		})
+		payload = payload[length:]
+	}
+	return commands, nil
+}
+
+func boolByte(v bool) byte {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// putString 固定長のnull終端文字列を書き込む
+func putString(b []byte, s string) {
+	n := copy(b, s)
+	for i := n; i < len(b); i++ {
+		b[i] = 0
+	}
+}
+
+func versionCommand(major, minor uint16) command {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint16(data[0:2], major)
+	binary.BigEndian.PutUint16(data[2:4], minor)
+	return command{name: "_ver", data: data}
+}
+
+func productCommand(name string, modelID uint8) command {
+	data := make([]byte, 44)
+	putString(data[0:40], name)
+	data[40] = modelID
+	return command{name: "_pin", data: data}
+}
+
+func topologyCommand(m Model) command {
+	data := make([]byte, 28)
+	data[0] = m.MixEffects
+	data[1] = uint8(len(m.sources()))
+	data[2] = m.DSKs
+	data[3] = m.Aux
+	return command{name: "_top", data: data}
+}
+
+func videoModeCommand(mode uint8) command {
+	return command{name: "VidM", data: []byte{mode, 0, 0, 0}}
+}
+
+func mixEffectConfigCommand(meIndex, keyers uint8) command {
+	return command{name: "_MeC", data: []byte{meIndex, keyers, 0, 0}}
+}
+
+func inputPropertiesCommand(src source) command {
+	data := make([]byte, 36)
+	binary.BigEndian.PutUint16(data[0:2], src.id)
+	putString(data[2:22], src.longName)
+	putString(data[22:26], src.shortName)
+	data[26] = 1
+	data[34] = 0xff // 全ての出力で選択可能
+	data[35] = 0xff // 全てのM/Eで選択可能
+	return command{name: "InPr", data: data}
+}
+
+func programInputCommand(meIndex uint8, input uint16) command {
+	data := make([]byte, 4)
+	data[0] = meIndex
+	binary.BigEndian.PutUint16(data[2:4], input)
+	return command{name: "PrgI", data: data}
+}
+
+func previewInputCommand(meIndex uint8, input uint16) command {
+	data := make([]byte, 8)
+	data[0] = meIndex
+	binary.BigEndian.PutUint16(data[2:4], input)
+	return command{name: "PrvI", data: data}
+}
+
+func transitionPositionCommand(meIndex uint8, inTransition bool, framesRemaining uint8, position uint16) command {
+	data := make([]byte, 8)
+	data[0] = meIndex
+	data[1] = boolByte(inTransition)
+	data[2] = framesRemaining
+	binary.BigEndian.PutUint16(data[4:6], position)
+	return command{name: "TrPs", data: data}
+}
+
+func keyerOnAirCommand(meIndex, keyer uint8, onAir bool) command {
+	return command{name: "KeOn", data: []byte{meIndex, keyer, boolByte(onAir), 0}}
+}
+
+func dskStateCommand(index uint8, onAir, inTransition bool) command {
+	return command{name: "DskS", data: []byte{index, boolByte(onAir), boolByte(inTransition), 0, 0, 0, 0, 0}}
+}
+
+func fadeToBlackStateCommand(meIndex uint8, fullyBlack, inTransition bool) command {
+	return command{name: "FtbS", data: []byte{meIndex, boolByte(fullyBlack), boolByte(inTransition), 0}}
+}
+
+func auxSourceCommand(index uint8, source uint16) command {
+	data := make([]byte, 4)
+	data[0] = index
+	binary.BigEndian.PutUint16(data[2:4], source)
+	return command{name: "AuxS", data: data}
+}
+
+func initCompleteCommand() command {
+	return command{name: "InCm", data: []byte{1, 0, 0, 0}}
+}
+
+// packCommands コマンドをパケットに収まるペイロードにまとめる
+func packCommands(commands []command) [][]byte {
+	var payloads [][]byte
+	var current []byte
+	for _, c := range commands {
+		if len(current) > 0 && headerLength+len(current)+c.length() > maxPacketSize {
+			payloads = append(payloads, current)
+			current = nil
+		}
+		current = append(current, c.marshal()...)
+	}
+	if len(current) > 0 {
+		payloads = append(payloads, current)
+	}
+	return payloads
+}
@@ -0,0 +1,122 @@
+package atemsim
+
+import "fmt"
+
+// Model シミュレートするATEMの機種構成
+type Model struct {
+	Name            string
+	ModelID         uint8
+	ProtocolMajor   uint16
+	ProtocolMinor   uint16
+	Inputs          uint16 // 外部入力の数
+	MixEffects      uint8
+	KeyersPerME     uint8
+	DSKs            uint8
+	Aux             uint8
+	TransitionRate  uint8 // Autoトランジションのフレーム数
+	FramesPerSecond uint8
+}
+
+var (
+	// ModelMini ATEM Mini
+	ModelMini = Model{
+		Name:            "ATEM Mini",
+		ModelID:         0x0d,
+		ProtocolMajor:   2,
+		ProtocolMinor:   30,
+		Inputs:          4,
+		MixEffects:      1,
+		KeyersPerME:     1,
+		DSKs:            1,
+		Aux:             1,
+		TransitionRate:  25,
+		FramesPerSecond: 25,
+	}
+
+	// ModelMiniExtreme ATEM Mini Extreme
+	ModelMiniExtreme = Model{
+		Name:            "ATEM Mini Extreme",
+		ModelID:         0x10,
+		ProtocolMajor:   2,
+		ProtocolMinor:   30,
+		Inputs:          8,
+		MixEffects:      1,
+		KeyersPerME:     4,
+		DSKs:            2,
+		Aux:             2,
+		TransitionRate:  25,
+		FramesPerSecond: 25,
+	}
+
+	// ModelTwoME ATEM 2 M/E Production Studio
+	ModelTwoME = Model{
+		Name:            "ATEM 2 M/E Production Studio 4K",
+		ModelID:         0x06,
+		ProtocolMajor:   2,
+		ProtocolMinor:   30,
+		Inputs:          20,
+		MixEffects:      2,
+		KeyersPerME:     4,
+		DSKs:            2,
+		Aux:             6,
+		TransitionRate:  25,
+		FramesPerSecond: 25,
+	}
+)
+
+// Models 名前で指定できる機種の一覧
+var Models = map[string]Model{
+	"mini":        ModelMini,
+	"miniextreme": ModelMiniExtreme,
+	"2me":         ModelTwoME,
+}
+
+// source 選択可能なビデオソース
+type source struct {
+	id        uint16
+	longName  string
+	shortName string
+}
+
+func (m Model) sources() []source {
+	sources := []source{{id: 0, longName: "Black", shortName: "BLK"}}
+	for i := uint16(1); i <= m.Inputs; i++ {
+		sources = append(sources, source{
+			id:        i,
+			longName:  fmt.Sprintf("Camera %d", i),
+			shortName: fmt.Sprintf("CAM%d", i),
+		})
+	}
+	sources = append(sources,
+		source{id: 1000, longName: "Color Bars", shortName: "BARS"},
+		source{id: 2001, longName: "Color 1", shortName: "COL1"},
+		source{id: 2002, longName: "Color 2", shortName: "COL2"},
+		source{id: 3010, longName: "Media Player 1", shortName: "MP1"},
+		source{id: 3020, longName: "Media Player 2", shortName: "MP2"},
+	)
+	return sources
+}
+
+// videoMode フレームレートに対応する1080pの映像フォーマットの番号
+func (m Model) videoMode() uint8 {
+	switch m.FramesPerSecond {
+	case 24:
+		return 9
+	case 30:
+		return 11
+	case 50:
+		return 12
+	case 60:
+		return 13
+	}
+	return 10 // 1080p25
+}
+
+func (m Model) hasSource(id uint16) bool {
+	for _, src := range m.sources() {
+		if src.id == id {
+			return true
+		}
+	}
+	return false
+}
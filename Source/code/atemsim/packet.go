@@ -0,0 +1,84 @@
+package atemsim
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/xerrors"
+)
+
+// packetFlag ATEMパケットヘッダのフラグ
+type packetFlag uint8
+
+const (
+	flagAckRequest        packetFlag = 0x01
+	flagNewSessionID      packetFlag = 0x02
+	flagIsRetransmit      packetFlag = 0x04
+	flagRetransmitRequest packetFlag = 0x08
+	flagAckReply          packetFlag = 0x10
+)
+
+const (
+	headerLength = 12
+	// helloLength ハンドシェイクパケットの長さ
+	helloLength = 20
+	// maxPacketID パケットIDは15bitで折り返す
+	maxPacketID = 1 << 15
+	// maxPacketSize 1パケットあたりの最大長
+	maxPacketSize = 1416
+)
+
+const (
+	helloConnect  byte = 0x01
+	helloAccepted byte = 0x02
+)
+
+// packet ATEM UDPプロトコルのパケット
+type packet struct {
+	flags            packetFlag
+	sessionID        uint16
+	ackPacketID      uint16 // flagAckReplyの時、確認応答するパケットID
+	retransmitFromID uint16 // flagRetransmitRequestの時、再送を要求するパケットID
+	packetID         uint16 // flagAckRequestの時、このパケットのID
+	payload          []byte
+}
+
+func (p *packet) has(f packetFlag) bool {
+	return p.flags&f != 0
+}
+
+func parsePacket(b []byte) (*packet, error) {
+	if len(b) < headerLength {
+		return nil, xerrors.Errorf("パケットが短すぎます: %d bytes", len(b))
+	}
+	length := int(binary.BigEndian.Uint16(b[0:2]) & 0x07ff)
+	if length != len(b) {
+		return nil, xerrors.Errorf("パケット長が一致しません: header:%d actual:%d", length, len(b))
+	}
+
+	return &packet{
+		flags:            packetFlag(b[0] >> 3),
+		sessionID:        binary.BigEndian.Uint16(b[2:4]),
+		ackPacketID:      binary.BigEndian.Uint16(b[4:6]),
+		retransmitFromID: binary.BigEndian.Uint16(b[6:8]),
+		packetID:         binary.BigEndian.Uint16(b[10:12]),
+		payload:          b[headerLength:],
+	}, nil
+}
+
+func (p *packet) marshal() []byte {
+	length := headerLength + len(p.payload)
+	b := make([]byte, length)
+	binary.BigEndian.PutUint16(b[0:2], uint16(p.flags)<<11|uint16(length)&0x07ff)
+	binary.BigEndian.PutUint16(b[2:4], p.sessionID)
+	binary.BigEndian.PutUint16(b[4:6], p.ackPacketID)
+	binary.BigEndian.PutUint16(b[6:8], p.retransmitFromID)
+	binary.BigEndian.PutUint16(b[10:12], p.packetID)
+	copy(b[headerLength:], p.payload)
+	return b
+}
+
+// packetIDBefore aがbより前に送られたパケットIDかどうか
+func packetIDBefore(a, b uint16) bool {
+	diff := (int(b) - int(a) + maxPacketID) % maxPacketID
+	return diff != 0 && diff < maxPacketID/2
+}
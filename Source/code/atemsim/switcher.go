@@ -0,0 +1,289 @@
+package atemsim
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// handleCommand クライアントから受信したコマンドをスイッチャー状態に反映する
+func (s *server) handleCommand(ctx context.Context, c command) {
+	var err error
+	switch c.name {
+	case "CPgI":
+		if len(c.data) >= 4 {
+			err = s.SetProgramInput(c.data[0], binary.BigEndian.Uint16(c.data[2:4]))
+		}
+	case "CPvI":
+		if len(c.data) >= 4 {
+			err = s.SetPreviewInput(c.data[0], binary.BigEndian.Uint16(c.data[2:4]))
+		}
+	case "DCut":
+		if len(c.data) >= 1 {
+			err = s.Cut(c.data[0])
+		}
+	case "DAut":
+		if len(c.data) >= 1 {
+			err = s.Auto(c.data[0])
+		}
+	case "FtbA":
+		if len(c.data) >= 1 {
+			err = s.fadeToBlack(c.data[0])
+		}
+	case "CKOn":
+		if len(c.data) >= 3 {
+			err = s.setKeyerOnAir(c.data[0], c.data[1], c.data[2] != 0)
+		}
+	case "CDsL":
+		if len(c.data) >= 2 {
+			err = s.setDSKOnAir(c.data[0], c.data[1] != 0)
+		}
+	case "DDsA":
+		if len(c.data) >= 1 {
+			err = s.autoDSK(c.data[0])
+		}
+	case "CAuS":
+		if len(c.data) >= 4 {
+			err = s.setAuxSource(c.data[1], binary.BigEndian.Uint16(c.data[2:4]))
+		}
+	default:
+		s.debug(ctx, "未対応のコマンド %s を無視", c.name)
+		return
+	}
+	if err != nil {
+		s.debug(ctx, "コマンド %s の処理に失敗: %v", c.name, err)
+	}
+}
+
+func (s *server) checkME(meIndex uint8) error {
+	if int(meIndex) >= len(s.mes) {
+		return xerrors.Errorf("M/E %d は存在しません", meIndex)
+	}
+	return nil
+}
+
+func (s *server) checkSource(input uint16) error {
+	if !s.model.hasSource(input) {
+		return xerrors.Errorf("ソース %d は存在しません", input)
+	}
+	return nil
+}
+
+func (s *server) SetProgramInput(meIndex uint8, input uint16) error {
+	s.mu.Lock()
+	if err := s.checkME(meIndex); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if err := s.checkSource(input); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.mes[meIndex].program = input
+	s.mu.Unlock()
+
+	s.broadcast(programInputCommand(meIndex, input))
+	return nil
+}
+
+func (s *server) SetPreviewInput(meIndex uint8, input uint16) error {
+	s.mu.Lock()
+	if err := s.checkME(meIndex); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if err := s.checkSource(input); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.mes[meIndex].preview = input
+	s.mu.Unlock()
+
+	s.broadcast(previewInputCommand(meIndex, input))
+	return nil
+}
+
+func (s *server) Cut(meIndex uint8) error {
+	s.mu.Lock()
+	if err := s.checkME(meIndex); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	me := &s.mes[meIndex]
+	if me.inTransition {
+		s.mu.Unlock()
+		return xerrors.Errorf("M/E %d はトランジション中です", meIndex)
+	}
+	me.program, me.preview = me.preview, me.program
+	program, preview := me.program, me.preview
+	s.mu.Unlock()
+
+	s.broadcast(programInputCommand(meIndex, program), previewInputCommand(meIndex, preview))
+	return nil
+}
+
+func (s *server) Auto(meIndex uint8) error {
+	s.mu.Lock()
+	if err := s.checkME(meIndex); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	me := &s.mes[meIndex]
+	if me.inTransition {
+		s.mu.Unlock()
+		return xerrors.Errorf("M/E %d はトランジション中です", meIndex)
+	}
+	me.inTransition = true
+	s.mu.Unlock()
+
+	go s.runTransition(meIndex)
+	return nil
+}
+
+// frameDuration 1フレームの長さ
+func (s *server) frameDuration() time.Duration {
+	fps := s.model.FramesPerSecond
+	if fps == 0 {
+		fps = 25
+	}
+	return time.Second / time.Duration(fps)
+}
+
+// runTransition Autoトランジションをフレームごとに進める
+func (s *server) runTransition(meIndex uint8) {
+	rate := s.model.TransitionRate
+	if rate == 0 {
+		rate = 1
+	}
+	ticker := time.NewTicker(s.frameDuration())
+	defer ticker.Stop()
+
+	for frame := uint8(1); frame <= rate; frame++ {
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+		}
+
+		position := uint16(10000 * uint32(frame) / uint32(rate))
+		s.mu.Lock()
+		s.mes[meIndex].position = position
+		s.mu.Unlock()
+		s.broadcast(transitionPositionCommand(meIndex, true, rate-frame, position))
+	}
+
+	s.mu.Lock()
+	me := &s.mes[meIndex]
+	me.program, me.preview = me.preview, me.program
+	me.inTransition = false
+	me.position = 0
+	program, preview := me.program, me.preview
+	s.mu.Unlock()
+
+	s.broadcast(
+		programInputCommand(meIndex, program),
+		previewInputCommand(meIndex, preview),
+		transitionPositionCommand(meIndex, false, 0, 0),
+	)
+}
+
+func (s *server) fadeToBlack(meIndex uint8) error {
+	s.mu.Lock()
+	if err := s.checkME(meIndex); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	me := &s.mes[meIndex]
+	me.ftbInTransition = true
+	fullyBlack := me.ftbFullyBlack
+	s.mu.Unlock()
+
+	s.broadcast(fadeToBlackStateCommand(meIndex, fullyBlack, true))
+
+	go func() {
+		time.Sleep(s.frameDuration() * time.Duration(s.model.TransitionRate))
+		s.mu.Lock()
+		me := &s.mes[meIndex]
+		me.ftbFullyBlack = !me.ftbFullyBlack
+		me.ftbInTransition = false
+		fullyBlack := me.ftbFullyBlack
+		s.mu.Unlock()
+		s.broadcast(fadeToBlackStateCommand(meIndex, fullyBlack, false))
+	}()
+	return nil
+}
+
+func (s *server) setKeyerOnAir(meIndex, keyer uint8, onAir bool) error {
+	s.mu.Lock()
+	if err := s.checkME(meIndex); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if int(keyer) >= len(s.mes[meIndex].keyers) {
+		s.mu.Unlock()
+		return xerrors.Errorf("キーヤー %d は存在しません", keyer)
+	}
+	s.mes[meIndex].keyers[keyer] = onAir
+	s.mu.Unlock()
+
+	s.broadcast(keyerOnAirCommand(meIndex, keyer, onAir))
+	return nil
+}
+
+func (s *server) setDSKOnAir(index uint8, onAir bool) error {
+	s.mu.Lock()
+	if int(index) >= len(s.dsks) {
+		s.mu.Unlock()
+		return xerrors.Errorf("DSK %d は存在しません", index)
+	}
+	s.dsks[index].onAir = onAir
+	s.mu.Unlock()
+
+	s.broadcast(dskStateCommand(index, onAir, false))
+	return nil
+}
+
+func (s *server) autoDSK(index uint8) error {
+	s.mu.Lock()
+	if int(index) >= len(s.dsks) {
+		s.mu.Unlock()
+		return xerrors.Errorf("DSK %d は存在しません", index)
+	}
+	dsk := &s.dsks[index]
+	dsk.inTransition = true
+	onAir := dsk.onAir
+	s.mu.Unlock()
+
+	s.broadcast(dskStateCommand(index, onAir, true))
+
+	go func() {
+		time.Sleep(s.frameDuration() * time.Duration(s.model.TransitionRate))
+		s.mu.Lock()
+		dsk := &s.dsks[index]
+		dsk.onAir = !dsk.onAir
+		dsk.inTransition = false
+		onAir := dsk.onAir
+		s.mu.Unlock()
+		s.broadcast(dskStateCommand(index, onAir, false))
+	}()
+	return nil
+}
+
+func (s *server) setAuxSource(index uint8, source uint16) error {
+	s.mu.Lock()
+	if int(index) >= len(s.aux) {
+		s.mu.Unlock()
+		return xerrors.Errorf("AUX %d は存在しません", index)
+	}
+	if err := s.checkSource(source); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.aux[index] = source
+	s.mu.Unlock()
+
+	s.broadcast(auxSourceCommand(index, source))
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/atemsim"
+)
+
+// ATEM実機なしでプラグインを動作確認・リハーサルするためのシミュレーター
+func main() {
+	model := flag.String("model", "mini", "シミュレートする機種 (mini, miniextreme, 2me)")
+	addr := flag.String("addr", atemsim.DefaultAddress, "待ち受けアドレス")
+	drop := flag.Float64("drop", 0, "パケットを破棄する確率 (0.0-1.0)")
+	flag.Parse()
+
+	m, ok := atemsim.Models[*model]
+	if !ok {
+		log.Fatalf("不明な機種: %s\n", *model)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server, err := atemsim.NewServer(atemsim.Config{
+		Model:    m,
+		Address:  *addr,
+		DropRate: *drop,
+	})
+	if err != nil {
+		log.Fatalf("%v\n", err)
+	}
+
+	log.Printf("%s を %s でシミュレート中\n", m.Name, server.Addr())
+	if err := server.Run(ctx); err != nil {
+		log.Fatalf("%v\n", err)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	_ "embed"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/FlowingSPDG/std-atem/Source/code/di"
 	"github.com/FlowingSPDG/std-atem/Source/code/logger"
-	"github.com/FlowingSPDG/std-atem/Source/code/stdatem"
 )
 
 const (
@@ -16,23 +18,25 @@ const (
 )
 
 func main() {
-	ctx := context.Background()
-	sd, err := di.InitializeStreamDeckClient(ctx)
-	if err != nil {
-		log.Fatalf("%v\n", err)
+	// log.Fatalfは遅延実行を飛ばすため、接続やログを閉じてから終了コードを返す
+	if err := run(); err != nil {
+		log.Printf("%v\n", err)
+		os.Exit(1)
 	}
+}
+
+// run プラグインを実行し、終了時に全てのATEMとの接続とログを閉じる
+func run() error {
+	// SIGTERMやCtrl+Cで全てのATEMとの接続を閉じて終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	logLevel := logger.DebugLevel | logger.InfoLevel | logger.WarnLevel | logger.ErrorLevel
-	sdLogger, err := di.InitializeStreamDeckLogger(ctx, sd, logLevel)
+	// ログレベルはグローバル設定の受信後に設定に従って変更する
+	app, cleanup, err := di.InitializeApp(ctx, logger.DefaultLevel)
 	if err != nil {
-		log.Fatalf("%v\n", err)
+		return err
 	}
+	defer cleanup()
 
-	fileLogger := logger.NewFileLogger(ctx, logLevel)
-
-	multiLogger := logger.NewMultiLogger(logLevel, fileLogger, sdLogger)
-
-	if err := stdatem.Run(ctx, multiLogger, sd); err != nil {
-		log.Fatalf("%v\n", err)
-	}
+	return app.Run(ctx)
 }
@@ -3,9 +3,10 @@ package connectionmanager
 import (
 	"context"
 
-	"github.com/FlowingSPDG/go-atem"
+	"github.com/FlowingSPDG/std-atem/Source/code/atemclient"
 	"github.com/FlowingSPDG/std-atem/Source/code/logger"
-	"github.com/puzpuzpuz/xsync"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"github.com/puzpuzpuz/xsync/v3"
 )
 
 type ActionAndContext struct {
@@ -15,8 +16,20 @@ type ActionAndContext struct {
 
 // ATEMInstance represents a single ATEM connection
 type ATEMInstance struct {
-	Client      *atem.Atem
+	Client      atemclient.Client
+	State       state.Switcher // ATEMから受信した状態のキャッシュ
 	ReconnectCh chan struct{}
+	// CancelReconnect 再接続ループを停止する
+	CancelReconnect context.CancelFunc
+}
+
+// Close 再接続ループを停止し、ATEMとの接続を閉じる
+func (i *ATEMInstance) Close() {
+	if i.CancelReconnect != nil {
+		i.CancelReconnect()
+	}
+	i.Client.Close()
+	i.State.Close()
 }
 
 type ConnectionManager struct {
@@ -28,9 +41,9 @@ type ConnectionManager struct {
 
 func NewConnectionManager(logger logger.Logger) *ConnectionManager {
 	return &ConnectionManager{
-		atemByIP:      xsync.NewMapOf[*ATEMInstance](),
-		atemByContext: xsync.NewMapOf[*ATEMInstance](),
-		contextsByIP:  xsync.NewMapOf[[]ActionAndContext](),
+		atemByIP:      xsync.NewMapOf[string, *ATEMInstance](),
+		atemByContext: xsync.NewMapOf[string, *ATEMInstance](),
+		contextsByIP:  xsync.NewMapOf[string, []ActionAndContext](),
 		logger:        logger,
 	}
 }
@@ -59,19 +72,7 @@ func (a *ConnectionManager) SolveATEMByContext(ctx context.Context, context stri
 func (a *ConnectionManager) SolveContextsByIP(ctx context.Context, ip string) ([]ActionAndContext, bool) {
 	a.logger.Debug(ctx, "SolveContextsByIP ip:%s", ip)
 	// ipからStreamDeck contextを取得する
-	var contexts []ActionAndContext
-	var ok bool
-
-	a.contextsByIP.Range(func(key string, value []ActionAndContext) bool {
-		if key == ip {
-			contexts = append(contexts, value...)
-			ok = true
-			return false
-		}
-
-		return false
-	})
-
+	contexts, ok := a.contextsByIP.Load(ip)
 	if !ok {
 		a.logger.Error(ctx, "SolveContextsByIP ip:%s not found", ip)
 	}
@@ -79,48 +80,94 @@ func (a *ConnectionManager) SolveContextsByIP(ctx context.Context, ip string) ([
 	return contexts, ok
 }
 
+// Store contextをATEMInstanceに紐付ける
+// 既に別のATEMInstanceに紐づいていた場合は、先に古い紐付けを解除する
 func (a *ConnectionManager) Store(ctx context.Context, action, ip, context string, at *ATEMInstance) {
 	a.logger.Debug(ctx, "Store action:%s ip:%s context:%s", action, ip, context)
+	if prev, ok := a.atemByContext.Load(context); ok && prev != at {
+		a.DeleteATEMByContext(ctx, context)
+	}
+
 	a.atemByIP.Store(ip, at)
 	a.atemByContext.Store(context, at)
-	if contextIDs, ok := a.contextsByIP.Load(ip); !ok {
-		a.contextsByIP.Store(ip, []ActionAndContext{{Action: action, Context: context}})
-	} else {
-		a.contextsByIP.Store(ip, append(contextIDs, ActionAndContext{Action: action, Context: context}))
-	}
+	contexts, _ := a.contextsByIP.Load(ip)
+	contexts = removeContext(contexts, context)
+	a.contextsByIP.Store(ip, append(contexts, ActionAndContext{Action: action, Context: context}))
 }
 
 func (a *ConnectionManager) DeleteATEMByIP(ctx context.Context, ip string) {
 	a.logger.Debug(ctx, "DeleteATEMByIP ip:%s", ip)
-	a.atemByIP.Delete(ip)
+	at, ok := a.atemByIP.LoadAndDelete(ip)
+	if !ok {
+		return
+	}
 
 	// 削除処理
 	a.logger.Debug(ctx, "Delete closing ATEM client ip:%s", ip)
-	at, ok := a.SolveATEMByIP(ctx, ip)
-	if !ok {
-		return
+	if contexts, ok := a.contextsByIP.LoadAndDelete(ip); ok {
+		for _, c := range contexts {
+			a.atemByContext.Delete(c.Context)
+		}
 	}
-	at.Client.Close()
+	at.Close()
 }
 
 func (a *ConnectionManager) DeleteATEMByContext(ctx context.Context, contextID string) {
 	a.logger.Debug(ctx, "DeleteATEMByContext contextID:%s", contextID)
-	a.atemByContext.Delete(contextID)
-
-	// 該当のATEMInstanceを利用するcontextが無くなったら、ATEMInstanceを削除する
-	at, ok := a.SolveATEMByContext(ctx, contextID)
+	at, ok := a.atemByContext.LoadAndDelete(contextID)
 	if !ok {
 		return
 	}
-	contexts, ok := a.SolveContextsByIP(ctx, at.Client.Ip)
-	if ok {
-		if len(contexts) == 0 {
-			a.logger.Debug(ctx, "Delete closing ATEM client ip:%s", at.Client.Ip)
-			at, ok := a.SolveATEMByIP(ctx, at.Client.Ip)
-			if !ok {
-				return
-			}
-			at.Client.Close()
+
+	ip := at.Client.IP()
+	contexts, _ := a.contextsByIP.Load(ip)
+	contexts = removeContext(contexts, contextID)
+	if len(contexts) > 0 {
+		a.contextsByIP.Store(ip, contexts)
+		return
+	}
+
+	// 該当のATEMInstanceを利用するcontextが無くなったら、ATEMInstanceを削除する
+	a.logger.Debug(ctx, "Delete closing ATEM client ip:%s", ip)
+	a.contextsByIP.Delete(ip)
+	a.atemByIP.Delete(ip)
+	at.Close()
+}
+
+// removeContext contextIDを除いたスライスを返す
+func removeContext(contexts []ActionAndContext, contextID string) []ActionAndContext {
+	result := make([]ActionAndContext, 0, len(contexts))
+	for _, c := range contexts {
+		if c.Context != contextID {
+			result = append(result, c)
 		}
 	}
+	return result
+}
+
+// Range 管理している全てのATEMInstanceとその紐付けを列挙する。fがfalseを返すと終了する
+func (a *ConnectionManager) Range(f func(ip string, at *ATEMInstance, contexts []ActionAndContext) bool) {
+	a.atemByIP.Range(func(ip string, at *ATEMInstance) bool {
+		contexts, _ := a.contextsByIP.Load(ip)
+		return f(ip, at, contexts)
+	})
+}
+
+// CloseAll 全てのATEMとの接続を閉じ、管理情報を破棄する
+func (a *ConnectionManager) CloseAll(ctx context.Context) {
+	a.logger.Debug(ctx, "CloseAll")
+	a.atemByIP.Range(func(ip string, at *ATEMInstance) bool {
+		a.logger.Debug(ctx, "CloseAll closing ATEM client ip:%s", ip)
+		at.Close()
+		a.atemByIP.Delete(ip)
+		return true
+	})
+	a.atemByContext.Range(func(contextID string, _ *ATEMInstance) bool {
+		a.atemByContext.Delete(contextID)
+		return true
+	})
+	a.contextsByIP.Range(func(ip string, _ []ActionAndContext) bool {
+		a.contextsByIP.Delete(ip)
+		return true
+	})
 }
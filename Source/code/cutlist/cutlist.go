@@ -0,0 +1,192 @@
+// Package cutlist ライブスイッチングのPGMの履歴を記録し、EDL(CMX3600)、CSV、FCPXMLのマーカーとして書き出す
+//
+// ATEMはタイムコードを送信しないため、記録側のタイムコードはホストの時計から生成した時刻(Time of Day)を利用する。
+// ISO収録も時刻のタイムコードで収録されていれば、書き出したEDLからそのまま再構成できる。
+package cutlist
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	// DefaultFPS フレームレートの既定値
+	DefaultFPS = 30
+	// DefaultMaxCuts 保持するカットの数の既定値
+	DefaultMaxCuts = 10000
+
+	// reelLength CMX3600のリール名の最大長
+	reelLength = 8
+)
+
+// Cut PGMの切り替え1回分
+type Cut struct {
+	Time           time.Time `json:"time"`
+	Timecode       string    `json:"timecode,omitempty"`       // 記録時のタイムコード
+	TimecodeSource string    `json:"timecodeSource,omitempty"` // Timecodeの出所。"host-clock"はホストの時計から生成したもの
+	FPS            int       `json:"fps,omitempty"`            // Timecodeのフレームレート。スイッチャーの映像フォーマットから求める
+	MeIndex        uint8     `json:"meIndex"`
+	Input          uint16    `json:"input"`
+	Name           string    `json:"name"` // 入力のロングネーム
+	Reel           string    `json:"reel"` // 入力のショートネームから生成したリール名
+}
+
+// History PGMの履歴
+type History interface {
+	// Add PGMの切り替えを記録する
+	Add(c Cut)
+	// Cuts meIndexの履歴を古い順に返す
+	Cuts(meIndex uint8) []Cut
+	// Reset 履歴を消去して新しいセッションを開始し、消去したカットの数を返す
+	Reset() int
+}
+
+// NewHistory 最大maxCuts件を保持するHistoryを初期化する。0の場合はDefaultMaxCuts
+func NewHistory(maxCuts int) History {
+	if maxCuts <= 0 {
+		maxCuts = DefaultMaxCuts
+	}
+	return &history{maxCuts: maxCuts}
+}
+
+type history struct {
+	maxCuts int
+
+	mu   sync.Mutex // 以下を保護する
+	cuts []Cut
+}
+
+func (h *history) Add(c Cut) {
+	if c.Reel == "" {
+		c.Reel = ReelName(c.Name, c.Input)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cuts = append(h.cuts, c)
+	if len(h.cuts) > h.maxCuts {
+		h.cuts = h.cuts[len(h.cuts)-h.maxCuts:]
+	}
+}
+
+func (h *history) Cuts(meIndex uint8) []Cut {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cuts := make([]Cut, 0, len(h.cuts))
+	for _, c := range h.cuts {
+		if c.MeIndex == meIndex {
+			cuts = append(cuts, c)
+		}
+	}
+	return cuts
+}
+
+func (h *history) Reset() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := len(h.cuts)
+	h.cuts = nil
+	return n
+}
+
+// ReelName 入力名からCMX3600のリール名(英数字とアンダースコアで最大8文字)を生成する
+func ReelName(name string, input uint16) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if b.Len() >= reelLength {
+			break
+		}
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+		case r == ' ' || r == '_' || r == '-':
+			b.WriteByte('_')
+		}
+	}
+	if reel := strings.Trim(b.String(), "_"); reel != "" {
+		return reel
+	}
+	return fmt.Sprintf("IN%d", input)
+}
+
+// Timecode フレーム数で表したタイムコード
+type Timecode int64
+
+// ParseTimecode "HH:MM:SS:FF"をフレーム数にする。ドロップフレームには対応しない
+func ParseTimecode(s string, fps int) (Timecode, error) {
+	var h, m, sec, f int
+	if _, err := fmt.Sscanf(strings.ReplaceAll(s, ";", ":"), "%d:%d:%d:%d", &h, &m, &sec, &f); err != nil {
+		return 0, xerrors.Errorf("タイムコード %q の解析に失敗: %w", s, err)
+	}
+	return Timecode(((h*60+m)*60+sec)*fps + f), nil
+}
+
+// TimeOfDay 時刻のタイムコード
+func TimeOfDay(t time.Time, fps int) Timecode {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return Timecode(t.Sub(midnight) * time.Duration(fps) / time.Second)
+}
+
+// Format "HH:MM:SS:FF"の形式
+func (tc Timecode) Format(fps int) string {
+	frames := int64(tc)
+	f := frames % int64(fps)
+	s := frames / int64(fps)
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", (s/3600)%24, (s/60)%60, s%60, f)
+}
+
+// span 書き出し用に記録側の開始と終了を決めたカット
+type span struct {
+	Cut
+	In, Out Timecode
+}
+
+// spans カットごとの記録側の開始と終了を求める。最後のカットはendで終わる
+func spans(cuts []Cut, end time.Time, fps int) []span {
+	result := make([]span, 0, len(cuts))
+	for i, c := range cuts {
+		in := cutTimecode(c, fps)
+		var out Timecode
+		if i+1 < len(cuts) {
+			out = cutTimecode(cuts[i+1], fps)
+		} else {
+			out = in + Timecode(end.Sub(c.Time)*time.Duration(fps)/time.Second)
+		}
+		if out <= in {
+			// 同じフレーム内の切り替えや日付をまたいだ場合は1フレームとして扱う
+			out = in + 1
+		}
+		result = append(result, span{Cut: c, In: in, Out: out})
+	}
+	return result
+}
+
+// cutTimecode カットの記録側のタイムコードをfpsで求める
+// 記録時のフレームレートがfpsと異なる場合は、記録時のフレームレートで解釈してから変換する
+func cutTimecode(c Cut, fps int) Timecode {
+	if c.Timecode != "" {
+		rate := c.FPS
+		if rate <= 0 {
+			rate = fps
+		}
+		if tc, err := ParseTimecode(c.Timecode, rate); err == nil {
+			return tc * Timecode(fps) / Timecode(rate)
+		}
+	}
+	return TimeOfDay(c.Time, fps)
+}
+
+// RecordedFPS cutsを記録したフレームレート。映像フォーマットが途中で変わった場合は最後のものを返す
+// 記録されていない場合は0を返す
+func RecordedFPS(cuts []Cut) int {
+	for i := len(cuts) - 1; i >= 0; i-- {
+		if cuts[i].FPS > 0 {
+			return cuts[i].FPS
+		}
+	}
+	return 0
+}
@@ -0,0 +1,173 @@
+package cutlist
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReelName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input uint16
+		want  string
+	}{
+		{"Camera 1", 1, "CAMERA_1"},
+		{"Wide-Shot Left", 2, "WIDE_SHO"},
+		{"  cam ", 3, "CAM"},
+		{"カメラ", 4, "IN4"},
+		{"", 5, "IN5"},
+	}
+	for _, tt := range tests {
+		if got := ReelName(tt.name, tt.input); got != tt.want {
+			t.Errorf("ReelName(%q, %d) = %q, want %q", tt.name, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestTimecode(t *testing.T) {
+	tc, err := ParseTimecode("01:02:03:04", 30)
+	if err != nil {
+		t.Fatalf("ParseTimecode: %v", err)
+	}
+	if want := Timecode(((1*60+2)*60+3)*30 + 4); tc != want {
+		t.Errorf("ParseTimecode = %d, want %d", tc, want)
+	}
+	if got := tc.Format(30); got != "01:02:03:04" {
+		t.Errorf("Format = %q", got)
+	}
+	if _, err := ParseTimecode("01:02", 30); err == nil {
+		t.Error("不正なタイムコードでエラーになりません")
+	}
+
+	at := time.Date(2024, 1, 2, 10, 20, 30, 500*int(time.Millisecond), time.UTC)
+	if got := TimeOfDay(at, 30).Format(30); got != "10:20:30:15" {
+		t.Errorf("TimeOfDay = %q, want 10:20:30:15", got)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	h := NewHistory(2)
+	base := time.Now()
+	for i := 1; i <= 3; i++ {
+		h.Add(Cut{Time: base.Add(time.Duration(i) * time.Second), Input: uint16(i), Name: "Camera"})
+	}
+	h.Add(Cut{Time: base, MeIndex: 1, Input: 9})
+
+	cuts := h.Cuts(0)
+	if len(cuts) != 1 || cuts[0].Input != 3 {
+		t.Fatalf("Cuts(0) = %+v, 古いカットが破棄されていません", cuts)
+	}
+	if cuts[0].Reel != "CAMERA" {
+		t.Errorf("Reel = %q, want CAMERA", cuts[0].Reel)
+	}
+	if n := h.Reset(); n != 2 {
+		t.Errorf("Reset() = %d, want 2", n)
+	}
+	if len(h.Cuts(1)) != 0 {
+		t.Error("Reset後にカットが残っています")
+	}
+}
+
+func TestWriteEDL(t *testing.T) {
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	cuts := []Cut{
+		{Time: base, Input: 1, Name: "Camera 1", Reel: "CAM1"},
+		{Time: base.Add(2 * time.Second), Input: 2, Name: "Camera 2", Reel: "CAM2"},
+	}
+	var buf bytes.Buffer
+	if err := WriteEDL(&buf, "TEST", cuts, base.Add(3*time.Second), 30); err != nil {
+		t.Fatalf("WriteEDL: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"TITLE: TEST\r\n",
+		"001  CAM1     V     C        10:00:00:00 10:00:02:00 10:00:00:00 10:00:02:00\r\n",
+		"002  CAM2     V     C        10:00:02:00 10:00:03:00 10:00:02:00 10:00:03:00\r\n",
+		"* FROM CLIP NAME: Camera 2\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("EDLに %q が含まれていません:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteEDLTooManyEvents(t *testing.T) {
+	base := time.Now()
+	cuts := make([]Cut, MaxEDLEvents+1)
+	for i := range cuts {
+		cuts[i] = Cut{Time: base.Add(time.Duration(i) * time.Second), Input: 1, Reel: "CAM1"}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteEDL(&buf, "TEST", cuts, base.Add(time.Hour), 30); err == nil {
+		t.Fatal("イベント番号が折り返すEDLを書き出しました")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("エラー時に %d バイト書き込まれました", buf.Len())
+	}
+
+	buf.Reset()
+	if err := WriteEDL(&buf, "TEST", cuts[:MaxEDLEvents], base.Add(time.Hour), 30); err != nil {
+		t.Fatalf("WriteEDL(%d events): %v", MaxEDLEvents, err)
+	}
+	if !strings.Contains(buf.String(), "\r\n999  CAM1") {
+		t.Error("999番目のイベントが含まれていません")
+	}
+}
+
+func TestWriteRecordedFPS(t *testing.T) {
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	cuts := []Cut{
+		{Time: base, Timecode: "10:00:00:00", FPS: 25, Input: 1, Reel: "CAM1"},
+		{Time: base.Add(2 * time.Second), Timecode: "10:00:01:24", FPS: 25, Input: 2, Reel: "CAM2"},
+	}
+	// Property Inspectorのフレームレートより記録時のフレームレートを優先する
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatEDL, "TEST", cuts, base.Add(3*time.Second), 30); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	want := "001  CAM1     V     C        10:00:00:00 10:00:01:24 10:00:00:00 10:00:01:24\r\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("EDLに %q が含まれていません:\n%s", want, buf.String())
+	}
+}
+
+func TestCutTimecodeConvertsFPS(t *testing.T) {
+	c := Cut{Timecode: "00:00:01:25", FPS: 50}
+	if got, want := cutTimecode(c, 25), Timecode(25+12); got != want {
+		t.Errorf("cutTimecode = %d, want %d", got, want)
+	}
+	// 記録時のフレームレートが無い場合は書き出しのフレームレートで解釈する
+	if got, want := cutTimecode(Cut{Timecode: "00:00:01:02"}, 30), Timecode(32); got != want {
+		t.Errorf("cutTimecode = %d, want %d", got, want)
+	}
+}
+
+func TestFormatValid(t *testing.T) {
+	for _, f := range []Format{FormatEDL, FormatCSV, FormatFCPXML} {
+		if !f.Valid() {
+			t.Errorf("%q が無効と判定されました", f)
+		}
+	}
+	if Format("xml").Valid() {
+		t.Error("未対応の形式が有効と判定されました")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	cuts := []Cut{{Time: base, Input: 3, Name: "Camera 3", Reel: "CAM3"}}
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, "TEST", cuts, base.Add(time.Second), 30); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("行数 = %d, want 2:\n%s", len(lines), buf.String())
+	}
+	if want := "1,2024-01-02T10:00:00Z,10:00:00:00,10:00:01:00,30,3,CAM3,Camera 3"; lines[1] != want {
+		t.Errorf("CSV = %q, want %q", lines[1], want)
+	}
+}
@@ -0,0 +1,213 @@
+package cutlist
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// Format 書き出し形式
+type Format string
+
+const (
+	FormatEDL    Format = "edl"
+	FormatCSV    Format = "csv"
+	FormatFCPXML Format = "fcpxml"
+)
+
+// Valid 対応している書き出し形式かどうか
+func (f Format) Valid() bool {
+	switch f {
+	case FormatEDL, FormatCSV, FormatFCPXML:
+		return true
+	default:
+		return false
+	}
+}
+
+// Ext 書き出し形式に対応する拡張子
+func (f Format) Ext() string {
+	switch f {
+	case FormatCSV:
+		return ".csv"
+	case FormatFCPXML:
+		return ".fcpxml"
+	default:
+		return ".edl"
+	}
+}
+
+// Write cutsをformatで書き出す。最後のカットはendで終わる
+// 書き出しにはカットを記録したフレームレートを使い、fpsは記録されていない場合にだけ利用する
+func Write(w io.Writer, format Format, title string, cuts []Cut, end time.Time, fps int) error {
+	if recorded := RecordedFPS(cuts); recorded > 0 {
+		fps = recorded
+	}
+	if fps <= 0 {
+		fps = DefaultFPS
+	}
+	switch format {
+	case FormatEDL:
+		return WriteEDL(w, title, cuts, end, fps)
+	case FormatCSV:
+		return WriteCSV(w, cuts, end, fps)
+	case FormatFCPXML:
+		return WriteFCPXML(w, title, cuts, end, fps)
+	default:
+		return xerrors.Errorf("書き出し形式 %q には対応していません", format)
+	}
+}
+
+// MaxEDLEvents CMX3600のイベント番号は3桁のため、1つのEDLに書き出せるイベント数の上限
+const MaxEDLEvents = 999
+
+// WriteEDL CMX3600形式のEDLを書き出す。ソース側とレコード側に同じタイムコードを利用する
+// イベント番号が重複しないよう、MaxEDLEventsを超える場合は何も書き出さずにエラーを返す
+func WriteEDL(w io.Writer, title string, cuts []Cut, end time.Time, fps int) error {
+	events := spans(cuts, end, fps)
+	if len(events) > MaxEDLEvents {
+		return xerrors.Errorf("EDLに書き出せるイベントは%d個までです (%d個)。CSVかFCPXMLで書き出してください", MaxEDLEvents, len(events))
+	}
+	if _, err := fmt.Fprintf(w, "TITLE: %s\r\nFCM: NON-DROP FRAME\r\n\r\n", title); err != nil {
+		return xerrors.Errorf("EDLの書き込みに失敗: %w", err)
+	}
+	for i, s := range events {
+		in, out := s.In.Format(fps), s.Out.Format(fps)
+		if _, err := fmt.Fprintf(w, "%03d  %-8s V     C        %s %s %s %s\r\n* FROM CLIP NAME: %s\r\n\r\n",
+			i+1, s.Reel, in, out, in, out, s.Name); err != nil {
+			return xerrors.Errorf("EDLの書き込みに失敗: %w", err)
+		}
+	}
+	return nil
+}
+
+// WriteCSV マーカーの一覧をCSVで書き出す
+func WriteCSV(w io.Writer, cuts []Cut, end time.Time, fps int) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"index", "time", "record_in", "record_out", "duration_frames", "input", "reel", "name"})
+	for i, s := range spans(cuts, end, fps) {
+		cw.Write([]string{
+			strconv.Itoa(i + 1),
+			s.Time.Format(time.RFC3339Nano),
+			s.In.Format(fps),
+			s.Out.Format(fps),
+			strconv.FormatInt(int64(s.Out-s.In), 10),
+			strconv.Itoa(int(s.Input)),
+			s.Reel,
+			s.Name,
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return xerrors.Errorf("CSVの書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+// fcpxml FCPXMLのうち、マーカーを並べたギャップのみのプロジェクト
+type fcpxml struct {
+	XMLName xml.Name    `xml:"fcpxml"`
+	Version string      `xml:"version,attr"`
+	Format  fcpxmlFmt   `xml:"resources>format"`
+	Event   fcpxmlEvent `xml:"library>event"`
+}
+
+type fcpxmlEvent struct {
+	Name    string     `xml:"name,attr"`
+	Project fcpxmlProj `xml:"project"`
+}
+
+type fcpxmlFmt struct {
+	ID            string `xml:"id,attr"`
+	Name          string `xml:"name,attr,omitempty"`
+	FrameDuration string `xml:"frameDuration,attr"`
+}
+
+type fcpxmlProj struct {
+	Name     string         `xml:"name,attr"`
+	Sequence fcpxmlSequence `xml:"sequence"`
+}
+
+type fcpxmlSequence struct {
+	Format   string    `xml:"format,attr"`
+	TCStart  string    `xml:"tcStart,attr"`
+	TCFormat string    `xml:"tcFormat,attr"`
+	Duration string    `xml:"duration,attr"`
+	Gap      fcpxmlGap `xml:"spine>gap"`
+}
+
+type fcpxmlGap struct {
+	Name     string         `xml:"name,attr"`
+	Offset   string         `xml:"offset,attr"`
+	Start    string         `xml:"start,attr"`
+	Duration string         `xml:"duration,attr"`
+	Markers  []fcpxmlMarker `xml:"marker"`
+}
+
+type fcpxmlMarker struct {
+	Start    string `xml:"start,attr"`
+	Duration string `xml:"duration,attr"`
+	Value    string `xml:"value,attr"`
+	Note     string `xml:"note,attr,omitempty"`
+}
+
+// WriteFCPXML カットごとのマーカーをFCPXML 1.9で書き出す
+func WriteFCPXML(w io.Writer, title string, cuts []Cut, end time.Time, fps int) error {
+	rational := func(frames Timecode) string {
+		return fmt.Sprintf("%d/%ds", frames, fps)
+	}
+
+	s := spans(cuts, end, fps)
+	var start, duration Timecode
+	if len(s) > 0 {
+		start = s[0].In
+		duration = s[len(s)-1].Out - start
+	}
+
+	doc := fcpxml{
+		Version: "1.9",
+		Format:  fcpxmlFmt{ID: "r1", FrameDuration: fmt.Sprintf("1/%ds", fps)},
+		Event: fcpxmlEvent{
+			Name: title,
+			Project: fcpxmlProj{
+				Name: title,
+				Sequence: fcpxmlSequence{
+					Format:   "r1",
+					TCStart:  rational(start),
+					TCFormat: "NDF",
+					Duration: rational(duration),
+					Gap: fcpxmlGap{
+						Name:     "Live Switching",
+						Offset:   rational(start),
+						Start:    rational(start),
+						Duration: rational(duration),
+					},
+				},
+			},
+		},
+	}
+	gap := &doc.Event.Project.Sequence.Gap
+	for _, c := range s {
+		gap.Markers = append(gap.Markers, fcpxmlMarker{
+			Start:    rational(c.In),
+			Duration: rational(1),
+			Value:    fmt.Sprintf("%s %s", c.Reel, c.Name),
+			Note:     fmt.Sprintf("Input %d (%d frames)", c.Input, c.Out-c.In),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header+"<!DOCTYPE fcpxml>\n"); err != nil {
+		return xerrors.Errorf("FCPXMLの書き込みに失敗: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return xerrors.Errorf("FCPXMLの書き込みに失敗: %w", err)
+	}
+	return nil
+}
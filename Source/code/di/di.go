@@ -2,24 +2,88 @@ package di
 
 import (
 	"context"
+	"log/slog"
 	"os"
+	"path/filepath"
 
+	"github.com/FlowingSPDG/std-atem/Source/code/atemclient"
 	"github.com/FlowingSPDG/std-atem/Source/code/logger"
+	"github.com/FlowingSPDG/std-atem/Source/code/stdatem"
 
 	"github.com/FlowingSPDG/streamdeck"
 	"golang.org/x/xerrors"
 )
 
-func InitializeStreamDeckClient(ctx context.Context) (*streamdeck.Client, error) {
+// InitializeRegistrationParams コマンドライン引数からStreamDeckの登録パラメータを取得する
+func InitializeRegistrationParams() (streamdeck.RegistrationParams, error) {
 	params, err := streamdeck.ParseRegistrationParams(os.Args)
 	if err != nil {
-		return nil, xerrors.Errorf("registration paramsの解析に失敗: %w", err)
+		return streamdeck.RegistrationParams{}, xerrors.Errorf("registration paramsの解析に失敗: %w", err)
 	}
+	return params, nil
+}
+
+func InitializeStreamDeckClient(ctx context.Context, params streamdeck.RegistrationParams) (*streamdeck.Client, error) {
 	sd := streamdeck.NewClient(ctx, params)
 	return sd, nil
 }
 
-func InitializeStreamDeckLogger(ctx context.Context, sd *streamdeck.Client, logLevel logger.LogLevel) (logger.Logger, error) {
-	logger := logger.NewStreamDeckLogger(sd, logLevel)
-	return logger, nil
+// logDirName プラグインのフォルダ以下のログの出力先
+const logDirName = "logs"
+
+// InitializePluginDir プラグインの実行ファイルがあるフォルダを返す。取得できない場合はカレントディレクトリとする
+func InitializePluginDir() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "."
+	}
+	return filepath.Dir(exe)
+}
+
+// InitializeLogDir プラグインのフォルダ以下のログの出力先を返す
+func InitializeLogDir(pluginDir string) string {
+	return filepath.Join(pluginDir, logDirName)
+}
+
+// InitializeLogger Stream Deckのログとdir以下のローテーションするファイルに書き込むロガーを生成する
+// ファイルを作成できない場合はStream Deckのログのみに書き込む。返り値の関数でファイルを閉じる
+func InitializeLogger(ctx context.Context, sd *streamdeck.Client, dir string, logLevel slog.Level) (logger.Logger, func()) {
+	level := &slog.LevelVar{}
+	level.Set(logLevel)
+
+	sdHandler := logger.NewStreamDeckHandler(sd, level)
+	fileHandler, closer, err := logger.NewFileHandler(logger.FileConfig{Dir: dir}, level)
+	if err != nil {
+		l := logger.New(level, sdHandler)
+		l.Warn(ctx, "ログファイルを作成できないため、Stream Deckのログのみに出力します: %v", err)
+		return l, func() {}
+	}
+	return logger.New(level, fileHandler, sdHandler), func() { closer.Close() }
+}
+
+func InitializeATEMClientFactory() atemclient.Factory {
+	return atemclient.NewATEMClient
+}
+
+// InitializeApp StreamDeckクライアント、ロガー、Appを1組だけ生成して組み立てる
+// logLevelはグローバル設定を受信するまでのログレベル。返り値の関数で終了時にログファイルを閉じる
+func InitializeApp(ctx context.Context, logLevel slog.Level) (*stdatem.App, func(), error) {
+	params, err := InitializeRegistrationParams()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("StreamDeckクライアントの初期化に失敗: %w", err)
+	}
+	sd, err := InitializeStreamDeckClient(ctx, params)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("StreamDeckクライアントの初期化に失敗: %w", err)
+	}
+
+	pluginDir := InitializePluginDir()
+	logDir := InitializeLogDir(pluginDir)
+	l, cleanup := InitializeLogger(ctx, sd, logDir, logLevel)
+	app, err := stdatem.NewApp(ctx, l, sd, params.PluginUUID, pluginDir, logDir, InitializeATEMClientFactory())
+	if err != nil {
+		cleanup()
+		return nil, nil, xerrors.Errorf("アプリの初期化に失敗: %w", err)
+	}
+	return app, cleanup, nil
 }
@@ -0,0 +1,137 @@
+package diag
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"golang.org/x/xerrors"
+)
+
+// DefaultMaxLogSize 診断データに含めるログの合計の最大バイト数の既定値
+const DefaultMaxLogSize = 20 * 1024 * 1024
+
+// Bundle サポート用の診断データの内容
+type Bundle struct {
+	Report     Report
+	Settings   any                       // 秘匿情報を取り除いたグローバル設定
+	Snapshots  map[string]state.Snapshot // ホスト: 状態
+	LogDir     string                    // ログの出力先。空の場合はログを含めない
+	MaxLogSize int64                     // 含めるログの合計の最大バイト数。0の場合はDefaultMaxLogSize
+}
+
+// WriteBundle 診断データをzipでwに書き出す
+//
+//	report.json       診断情報
+//	settings.json     グローバル設定
+//	state/{host}.json スイッチャーの状態
+//	logs/*            新しいものから最大MaxLogSizeまでのログ
+func WriteBundle(w io.Writer, b Bundle) error {
+	zw := zip.NewWriter(w)
+	if err := writeJSON(zw, "report.json", b.Report); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "settings.json", b.Settings); err != nil {
+		return err
+	}
+	for host, snapshot := range b.Snapshots {
+		name := "state/" + strings.NewReplacer(":", "_", "/", "_").Replace(host) + ".json"
+		if err := writeJSON(zw, name, snapshot); err != nil {
+			return err
+		}
+	}
+	if b.LogDir != "" {
+		maxSize := b.MaxLogSize
+		if maxSize <= 0 {
+			maxSize = DefaultMaxLogSize
+		}
+		if err := writeLogs(zw, b.LogDir, maxSize); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return xerrors.Errorf("zipの書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return xerrors.Errorf("%s の作成に失敗: %w", name, err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return xerrors.Errorf("%s の書き込みに失敗: %w", name, err)
+	}
+	return nil
+}
+
+// writeLogs dirのログを新しい順にmaxSizeまで含める。上限を超えるファイルは末尾のみを含める
+func writeLogs(zw *zip.Writer, dir string, maxSize int64) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return xerrors.Errorf("ログの一覧の取得に失敗: %w", err)
+	}
+	type logFile struct {
+		name string
+		info os.FileInfo
+	}
+	var files []logFile
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{name: e.Name(), info: info})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].info.ModTime().After(files[j].info.ModTime())
+	})
+
+	remaining := maxSize
+	for _, lf := range files {
+		if remaining <= 0 {
+			break
+		}
+		if err := writeLog(zw, filepath.Join(dir, lf.name), lf.name, lf.info.Size(), remaining); err != nil {
+			return err
+		}
+		remaining -= min(lf.info.Size(), remaining)
+	}
+	return nil
+}
+
+func writeLog(zw *zip.Writer, path, name string, size, limit int64) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return xerrors.Errorf("ログ %s のオープンに失敗: %w", name, err)
+	}
+	defer src.Close()
+	if size > limit {
+		if _, err := src.Seek(size-limit, io.SeekStart); err != nil {
+			return xerrors.Errorf("ログ %s のシークに失敗: %w", name, err)
+		}
+	}
+
+	dst, err := zw.Create("logs/" + name)
+	if err != nil {
+		return xerrors.Errorf("logs/%s の作成に失敗: %w", name, err)
+	}
+	if _, err := io.CopyN(dst, src, min(size, limit)); err != nil && err != io.EOF {
+		return xerrors.Errorf("ログ %s の書き込みに失敗: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Package diag スイッチャーごとの接続の診断情報を集計し、サポート用の診断データ(zip)を書き出す
+package diag
+
+import (
+	"sync"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/atemclient"
+)
+
+// Link スイッチャーとの接続の履歴
+type Link struct {
+	ConnectedAt    time.Time `json:"connectedAt"`    // 最後に接続した時刻
+	DisconnectedAt time.Time `json:"disconnectedAt"` // 最後に切断された時刻
+	Disconnects    int       `json:"disconnects"`    // 切断された回数
+	LastError      string    `json:"lastError,omitempty"`
+	LastErrorAt    time.Time `json:"lastErrorAt"`
+}
+
+// Tracker スイッチャーごとの接続の履歴を記録する。並行して利用できる
+type Tracker interface {
+	Connected(host string)
+	Disconnected(host string)
+	// Error 接続や操作の送信に失敗したことを記録する
+	Error(host string, err error)
+	Link(host string) Link
+}
+
+// NewTracker Trackerを初期化する
+func NewTracker() Tracker {
+	return &tracker{links: map[string]*Link{}}
+}
+
+type tracker struct {
+	mu    sync.Mutex // linksを保護する
+	links map[string]*Link
+}
+
+func (t *tracker) update(host string, f func(l *Link)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l, ok := t.links[host]
+	if !ok {
+		l = &Link{}
+		t.links[host] = l
+	}
+	f(l)
+}
+
+func (t *tracker) Connected(host string) {
+	t.update(host, func(l *Link) {
+		l.ConnectedAt = time.Now()
+	})
+}
+
+func (t *tracker) Disconnected(host string) {
+	t.update(host, func(l *Link) {
+		l.DisconnectedAt = time.Now()
+		l.Disconnects++
+	})
+}
+
+func (t *tracker) Error(host string, err error) {
+	if err == nil {
+		return
+	}
+	t.update(host, func(l *Link) {
+		l.LastError = err.Error()
+		l.LastErrorAt = time.Now()
+	})
+}
+
+func (t *tracker) Link(host string) Link {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if l, ok := t.links[host]; ok {
+		return *l
+	}
+	return Link{}
+}
+
+// Context スイッチャーに紐づいたボタンやサービス
+type Context struct {
+	Context  string `json:"context"`
+	Action   string `json:"action"`
+	Switcher string `json:"switcher,omitempty"` // 名前付きスイッチャーの名前
+}
+
+// Switcher スイッチャー1台分の診断情報
+type Switcher struct {
+	Host      string `json:"host"`
+	Connected bool   `json:"connected"`
+	Link
+
+	// 以下はスイッチャーから製品情報を受信している場合のみ設定する
+	// 往復時間やパケット数はATEMクライアントが集計していないため記録しない
+	ProductName     string `json:"productName,omitempty"`
+	ProtocolVersion string `json:"protocolVersion,omitempty"`
+
+	Contexts []Context `json:"contexts"`
+}
+
+// SetStats スイッチャーの製品情報を設定する
+func (s *Switcher) SetStats(stats atemclient.Stats) {
+	s.ProductName = stats.ProductName
+	s.ProtocolVersion = stats.ProtocolVersion
+}
+
+// Report 全てのスイッチャーの診断情報
+type Report struct {
+	GeneratedAt time.Time  `json:"generatedAt"`
+	Switchers   []Switcher `json:"switchers"`
+}
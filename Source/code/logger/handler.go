@@ -0,0 +1,183 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/rotate"
+	"github.com/FlowingSPDG/streamdeck"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// DefaultMaxSize ログファイル1つの最大バイト数の既定値
+	DefaultMaxSize = 10 * 1024 * 1024
+	// DefaultMaxAge ログファイルを残す期間の既定値
+	DefaultMaxAge = 7 * 24 * time.Hour
+	// DefaultMaxFiles 残すログファイルの数の既定値
+	DefaultMaxFiles = 20
+)
+
+// multiHandler 全てのHandlerに書き込むslog.Handler
+type multiHandler struct {
+	handlers []slog.Handler
+}
+
+// NewMultiHandler 全てのhandlerに書き込むslog.Handlerを初期化する
+func NewMultiHandler(handlers ...slog.Handler) slog.Handler {
+	return &multiHandler{handlers: handlers}
+}
+
+func (h *multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(h.handlers))
+	for _, handler := range h.handlers {
+		handlers = append(handlers, handler.WithAttrs(attrs))
+	}
+	return &multiHandler{handlers: handlers}
+}
+
+func (h *multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(h.handlers))
+	for _, handler := range h.handlers {
+		handlers = append(handlers, handler.WithGroup(name))
+	}
+	return &multiHandler{handlers: handlers}
+}
+
+// streamDeckHandler Stream Deckのログに "[LEVEL] メッセージ key=value" の形式で書き込むslog.Handler
+type streamDeckHandler struct {
+	client *streamdeck.Client
+	level  slog.Leveler
+	attrs  string // WithAttrsで付与された属性を整形したもの
+	group  string // WithGroupで指定されたグループの接頭辞
+}
+
+// NewStreamDeckHandler Stream Deckのログに書き込むslog.Handlerを初期化する
+func NewStreamDeckHandler(client *streamdeck.Client, level slog.Leveler) slog.Handler {
+	return &streamDeckHandler{
+		client: client,
+		level:  level,
+	}
+}
+
+func (h *streamDeckHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *streamDeckHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.client == nil || !h.client.IsConnected() {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("[" + r.Level.String() + "] " + r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, h.group, a)
+		return true
+	})
+	return h.client.LogMessage(ctx, b.String())
+}
+
+func (h *streamDeckHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		appendAttr(&b, h.group, a)
+	}
+	h2 := *h
+	h2.attrs += b.String()
+	return &h2
+}
+
+func (h *streamDeckHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group += name + "."
+	return &h2
+}
+
+// appendAttr " key=value" の形式で属性を追記する。グループは "group.key" に展開する
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendAttr(b, prefix, ga)
+		}
+		return
+	}
+	value := a.Value.String()
+	if value == "" || strings.ContainsAny(value, " =\"") {
+		value = strconv.Quote(value)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, value)
+}
+
+// FileConfig ログファイルの設定
+type FileConfig struct {
+	Dir      string        // 出力先ディレクトリ
+	MaxSize  int64         // 1ファイルの最大バイト数。0の場合はDefaultMaxSize
+	MaxAge   time.Duration // これより古いファイルを削除する。0の場合はDefaultMaxAge
+	MaxFiles int           // 残すファイルの数。0の場合はDefaultMaxFiles
+}
+
+// NewFileHandler サイズと日付でローテーションするファイルにJSON Linesで書き込むslog.Handlerを初期化する
+// 返り値のio.Closerで終了時にファイルを閉じる
+func NewFileHandler(cfg FileConfig, level slog.Leveler) (slog.Handler, io.Closer, error) {
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultMaxSize
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = DefaultMaxAge
+	}
+	if cfg.MaxFiles <= 0 {
+		cfg.MaxFiles = DefaultMaxFiles
+	}
+	w, err := rotate.NewWriter(rotate.Config{
+		Dir:      cfg.Dir,
+		Name:     "plugin",
+		Ext:      ".log",
+		MaxSize:  cfg.MaxSize,
+		Daily:    true,
+		MaxAge:   cfg.MaxAge,
+		MaxFiles: cfg.MaxFiles,
+	})
+	if err != nil {
+		return nil, nil, xerrors.Errorf("ログファイルの作成に失敗: %w", err)
+	}
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}), w, nil
+}
@@ -1,245 +1,130 @@
+// Package logger log/slogを利用した構造化ログ
+//
+// メッセージはこれまで通りfmt形式で組み立て、Withで付与したkey/valueを属性として出力する。
+// 出力先はslog.Handlerとして実装し、Stream Deckのログとローテーションするファイルに同時に書き込む。
 package logger
 
 import (
 	"context"
 	"fmt"
-	"os"
+	"log/slog"
+	"strings"
 	"testing"
 
-	"github.com/FlowingSPDG/streamdeck"
+	"golang.org/x/xerrors"
 )
 
-// LogLevel represents the severity of a log message
-type LogLevel int
-
-const (
-	DebugLevel    LogLevel = 1 << 0 // 1
-	InfoLevel     LogLevel = 1 << 1 // 2
-	WarnLevel     LogLevel = 1 << 2 // 4
-	ErrorLevel    LogLevel = 1 << 3 // 8
-	CriticalLevel LogLevel = 1 << 4 // 16
-)
-
-func checkDebugLevel(level LogLevel) bool {
-	return level&DebugLevel != 0
-}
-
-func checkInfoLevel(level LogLevel) bool {
-	return level&InfoLevel != 0
-}
-
-func checkWarnLevel(level LogLevel) bool {
-	return level&WarnLevel != 0
-}
-
-func checkErrorLevel(level LogLevel) bool {
-	return level&ErrorLevel != 0
-}
-
-func checkCriticalLevel(level LogLevel) bool {
-	return level&CriticalLevel != 0
-}
+// DefaultLevel ログレベルの既定値
+const DefaultLevel = slog.LevelInfo
 
 type Logger interface {
-	LogMessage(ctx context.Context, format string, args ...any) error
 	Debug(ctx context.Context, format string, args ...any) error
 	Info(ctx context.Context, format string, args ...any) error
 	Warn(ctx context.Context, format string, args ...any) error
 	Error(ctx context.Context, format string, args ...any) error
+	// With key/valueの属性を付与したLoggerを返す
+	With(args ...any) Logger
+	// SetLevel 出力するログレベルを変更する。Withで派生したLoggerにも反映される
+	SetLevel(level slog.Level)
 }
 
-type streamDeckLogger struct {
-	client *streamdeck.Client
-	level  LogLevel
-}
-
-func NewStreamDeckLogger(client *streamdeck.Client, level LogLevel) Logger {
-	return &streamDeckLogger{
-		client: client,
-		level:  level,
+// ParseLevel "debug"、"info"、"warn"、"error"をログレベルにする。空の場合はDefaultLevel
+func ParseLevel(s string) (slog.Level, error) {
+	if s == "" {
+		return DefaultLevel, nil
 	}
-}
-
-func (l *streamDeckLogger) LogMessage(ctx context.Context, format string, args ...any) error {
-	msg := fmt.Sprintf(format, args...)
-	if l.client == nil {
-		return nil
-	}
-	if !l.client.IsConnected() {
-		return nil
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return DefaultLevel, xerrors.Errorf("ログレベル %q が不正です: %w", s, err)
 	}
-	return l.client.LogMessage(ctx, msg)
+	return level, nil
 }
 
-func (l *streamDeckLogger) Debug(ctx context.Context, format string, args ...any) error {
-	if !checkDebugLevel(l.level) {
-		return nil
-	}
-	return l.LogMessage(ctx, "[DEBUG] "+format, args...)
+type slogLogger struct {
+	logger *slog.Logger
+	level  *slog.LevelVar
 }
 
-func (l *streamDeckLogger) Info(ctx context.Context, format string, args ...any) error {
-	if !checkInfoLevel(l.level) {
-		return nil
-	}
-	return l.LogMessage(ctx, "[INFO] "+format, args...)
-}
-
-func (l *streamDeckLogger) Warn(ctx context.Context, format string, args ...any) error {
-	if !checkWarnLevel(l.level) {
-		return nil
+// New levelで出力を制御し、全てのhandlerに書き込むLoggerを初期化する
+// handlerにも同じlevelを指定すること
+func New(level *slog.LevelVar, handlers ...slog.Handler) Logger {
+	return &slogLogger{
+		logger: slog.New(NewMultiHandler(handlers...)),
+		level:  level,
 	}
-	return l.LogMessage(ctx, "[WARN] "+format, args...)
 }
 
-func (l *streamDeckLogger) Error(ctx context.Context, format string, args ...any) error {
-	if !checkErrorLevel(l.level) {
+func (l *slogLogger) log(ctx context.Context, level slog.Level, format string, args ...any) error {
+	if !l.logger.Enabled(ctx, level) {
 		return nil
 	}
-	return l.LogMessage(ctx, "[ERROR] "+format, args...)
-}
-
-type fileLogger struct {
-	file  *os.File
-	level LogLevel
-}
-
-func NewFileLogger(ctx context.Context, level LogLevel) Logger {
-	file, err := os.OpenFile("./log.txt", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		panic(err)
-	}
-	return &fileLogger{
-		file:  file,
-		level: level,
-	}
-}
-
-func (l *fileLogger) LogMessage(ctx context.Context, format string, args ...any) error {
-	msg := fmt.Sprintf(format, args...)
-	_, err := l.file.WriteString(msg + "\n")
-	if err != nil {
-		return err
-	}
+	l.logger.Log(ctx, level, fmt.Sprintf(format, args...))
 	return nil
 }
 
-func (l *fileLogger) Debug(ctx context.Context, format string, args ...any) error {
-	if !checkDebugLevel(l.level) {
-		return nil
-	}
-	return l.LogMessage(ctx, "[DEBUG] "+format, args...)
-}
-
-func (l *fileLogger) Info(ctx context.Context, format string, args ...any) error {
-	if !checkInfoLevel(l.level) {
-		return nil
-	}
-	return l.LogMessage(ctx, "[INFO] "+format, args...)
-}
-
-func (l *fileLogger) Warn(ctx context.Context, format string, args ...any) error {
-	if !checkWarnLevel(l.level) {
-		return nil
-	}
-	return l.LogMessage(ctx, "[WARN] "+format, args...)
+func (l *slogLogger) Debug(ctx context.Context, format string, args ...any) error {
+	return l.log(ctx, slog.LevelDebug, format, args...)
 }
 
-func (l *fileLogger) Error(ctx context.Context, format string, args ...any) error {
-	if !checkErrorLevel(l.level) {
-		return nil
-	}
-	return l.LogMessage(ctx, "[ERROR] "+format, args...)
+func (l *slogLogger) Info(ctx context.Context, format string, args ...any) error {
+	return l.log(ctx, slog.LevelInfo, format, args...)
 }
 
-type multiLogger struct {
-	loggers []Logger
-	level   LogLevel
+func (l *slogLogger) Warn(ctx context.Context, format string, args ...any) error {
+	return l.log(ctx, slog.LevelWarn, format, args...)
 }
 
-func NewMultiLogger(level LogLevel, loggers ...Logger) Logger {
-	return &multiLogger{
-		loggers: loggers,
-		level:   level,
-	}
-}
-
-func (l *multiLogger) LogMessage(ctx context.Context, format string, args ...any) error {
-	for _, logger := range l.loggers {
-		logger.LogMessage(ctx, format, args...)
-	}
-	return nil
+func (l *slogLogger) Error(ctx context.Context, format string, args ...any) error {
+	return l.log(ctx, slog.LevelError, format, args...)
 }
 
-func (l *multiLogger) Debug(ctx context.Context, format string, args ...any) error {
-	if !checkDebugLevel(l.level) {
-		return nil
-	}
-	for _, logger := range l.loggers {
-		logger.Debug(ctx, format, args...)
+func (l *slogLogger) With(args ...any) Logger {
+	return &slogLogger{
+		logger: l.logger.With(args...),
+		level:  l.level,
 	}
-	return nil
 }
 
-func (l *multiLogger) Info(ctx context.Context, format string, args ...any) error {
-	if !checkInfoLevel(l.level) {
-		return nil
-	}
-	for _, logger := range l.loggers {
-		logger.Info(ctx, format, args...)
-	}
-	return nil
-}
-
-func (l *multiLogger) Warn(ctx context.Context, format string, args ...any) error {
-	if !checkWarnLevel(l.level) {
-		return nil
-	}
-	for _, logger := range l.loggers {
-		logger.Warn(ctx, format, args...)
-	}
-	return nil
-}
-
-func (l *multiLogger) Error(ctx context.Context, format string, args ...any) error {
-	if !checkErrorLevel(l.level) {
-		return nil
-	}
-	for _, logger := range l.loggers {
-		logger.Error(ctx, format, args...)
-	}
-	return nil
+func (l *slogLogger) SetLevel(level slog.Level) {
+	l.level.Set(level)
 }
 
 type testLogger struct {
-	t *testing.T
+	t     *testing.T
+	attrs []any
 }
 
 func NewTestLogger(t *testing.T) Logger {
 	return &testLogger{t: t}
 }
 
-func (l *testLogger) LogMessage(ctx context.Context, format string, args ...any) error {
+func (l *testLogger) logf(format string, args ...any) error {
+	if len(l.attrs) > 0 {
+		l.t.Logf("%s %v", fmt.Sprintf(format, args...), l.attrs)
+		return nil
+	}
 	l.t.Logf(format, args...)
 	return nil
 }
 
 func (l *testLogger) Debug(ctx context.Context, format string, args ...any) error {
-	l.t.Logf(format, args...)
-	return nil
+	return l.logf(format, args...)
 }
 
 func (l *testLogger) Info(ctx context.Context, format string, args ...any) error {
-	l.t.Logf(format, args...)
-	return nil
+	return l.logf(format, args...)
 }
 
 func (l *testLogger) Warn(ctx context.Context, format string, args ...any) error {
-	l.t.Logf(format, args...)
-	return nil
+	return l.logf(format, args...)
 }
 
 func (l *testLogger) Error(ctx context.Context, format string, args ...any) error {
-	l.t.Logf(format, args...)
-	return nil
+	return l.logf(format, args...)
 }
+
+func (l *testLogger) With(args ...any) Logger {
+	return &testLogger{t: l.t, attrs: append(append([]any{}, l.attrs...), args...)}
+}
+
+func (l *testLogger) SetLevel(slog.Level) {}
@@ -0,0 +1,297 @@
+// Package metrics カウンター、ゲージ、ヒストグラムを集計し、Prometheusのテキスト形式で公開する
+//
+// ラベルの値はメトリクスの登録時に指定したラベル名の順に渡す。
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType Prometheusのテキスト形式
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets 秒単位のレイテンシ向けのヒストグラムの既定のバケット
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// Counter 増加のみするカウンター
+type Counter interface {
+	Inc(labelValues ...string)
+	Add(v float64, labelValues ...string)
+}
+
+// Histogram 観測値の分布
+type Histogram interface {
+	Observe(v float64, labelValues ...string)
+}
+
+// Emit 収集時に値を1つ出力する
+type Emit func(value float64, labelValues ...string)
+
+// Registry メトリクスの登録と出力。並行して利用できる
+type Registry interface {
+	Counter(name, help string, labels ...string) Counter
+	Histogram(name, help string, buckets []float64, labels ...string) Histogram
+	// CounterFunc 出力のたびにcollectで値を求めるカウンター
+	CounterFunc(name, help string, labels []string, collect func(emit Emit))
+	// GaugeFunc 出力のたびにcollectで値を求めるゲージ
+	GaugeFunc(name, help string, labels []string, collect func(emit Emit))
+	// WriteTo 全てのメトリクスをテキスト形式で書き出す
+	WriteTo(w io.Writer) (int64, error)
+	// Handler GET /metrics 用のhttp.Handler
+	Handler() http.Handler
+}
+
+// NewRegistry Registryを初期化する
+func NewRegistry() Registry {
+	return &registry{}
+}
+
+type registry struct {
+	mu       sync.Mutex // familiesを保護する
+	families []family
+}
+
+// family 同じ名前のメトリクス
+type family interface {
+	write(w *bufio.Writer)
+}
+
+func (r *registry) add(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.families = append(r.families, f)
+}
+
+func (r *registry) Counter(name, help string, labels ...string) Counter {
+	c := &counter{header: header{name: name, help: help, typ: "counter", labels: labels}, values: map[string]*series{}}
+	r.add(c)
+	return c
+}
+
+func (r *registry) Histogram(name, help string, buckets []float64, labels ...string) Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &histogram{header: header{name: name, help: help, typ: "histogram", labels: labels}, buckets: buckets, values: map[string]*histogramSeries{}}
+	r.add(h)
+	return h
+}
+
+func (r *registry) CounterFunc(name, help string, labels []string, collect func(emit Emit)) {
+	r.add(&funcFamily{header: header{name: name, help: help, typ: "counter", labels: labels}, collect: collect})
+}
+
+func (r *registry) GaugeFunc(name, help string, labels []string, collect func(emit Emit)) {
+	r.add(&funcFamily{header: header{name: name, help: help, typ: "gauge", labels: labels}, collect: collect})
+}
+
+func (r *registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, f := range families {
+		f.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+func (r *registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		r.WriteTo(w)
+	})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// header メトリクスの名前と説明
+type header struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (h header) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", h.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(h.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", h.name, h.typ)
+}
+
+// key ラベルの値を連結したシリーズのキー
+func (h header) key(labelValues []string) string {
+	return strings.Join(h.normalize(labelValues), "\xff")
+}
+
+// normalize ラベルの値の数をラベル名の数に揃える
+func (h header) normalize(labelValues []string) []string {
+	if len(labelValues) == len(h.labels) {
+		return labelValues
+	}
+	values := make([]string, len(h.labels))
+	copy(values, labelValues)
+	return values
+}
+
+// labelString {name="value",...} の形式。extraは末尾に追加するラベル
+func (h header) labelString(labelValues []string, extra ...string) string {
+	labelValues = h.normalize(labelValues)
+	if len(h.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(h.labels)+len(extra)/2)
+	for i, name := range h.labels {
+		pairs = append(pairs, name+`="`+escapeLabel(labelValues[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys シリーズを出力順に並べる
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+}
+
+type counter struct {
+	header
+	mu     sync.Mutex // valuesを保護する
+	values map[string]*series
+}
+
+func (c *counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.values[key]
+	if !ok {
+		s = &series{labelValues: c.normalize(labelValues)}
+		c.values[key] = s
+	}
+	s.value += v
+}
+
+func (c *counter) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.values) {
+		s := c.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelString(s.labelValues), formatFloat(s.value))
+	}
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64 // バケットごとの数。累積ではない
+	count       uint64
+	sum         float64
+}
+
+type histogram struct {
+	header
+	buckets []float64
+	mu      sync.Mutex // valuesを保護する
+	values  map[string]*histogramSeries
+}
+
+func (h *histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.values[key]
+	if !ok {
+		s = &histogramSeries{labelValues: h.normalize(labelValues), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *histogram) write(w *bufio.Writer) {
+	h.writeHeader(w)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.values) {
+		s := h.values[key]
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(s.labelValues, "le", formatFloat(le)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(s.labelValues), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(s.labelValues), s.count)
+	}
+}
+
+type funcFamily struct {
+	header
+	collect func(emit Emit)
+}
+
+func (f *funcFamily) write(w *bufio.Writer) {
+	f.writeHeader(w)
+	f.collect(func(value float64, labelValues ...string) {
+		fmt.Fprintf(w, "%s%s %s\n", f.name, f.labelString(labelValues), formatFloat(value))
+	})
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWriteTo(t *testing.T) {
+	r := NewRegistry()
+	commands := r.Counter("stdatem_commands_total", "送信したコマンドの数", "host", "action")
+	latency := r.Histogram("stdatem_ack_seconds", "反映までの時間", []float64{0.1, 1}, "host")
+	r.GaugeFunc("stdatem_connected", "接続状態", []string{"host"}, func(emit Emit) {
+		emit(1, "10.0.0.1")
+	})
+
+	commands.Inc("10.0.0.1", "cut")
+	commands.Add(2, "10.0.0.1", "cut")
+	commands.Add(-1, "10.0.0.1", "cut") // 減少は無視する
+	commands.Inc(`a"b`)                 // 足りないラベルは空にする
+	latency.Observe(0.05, "10.0.0.1")
+	latency.Observe(0.5, "10.0.0.1")
+	latency.Observe(3, "10.0.0.1")
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	want := `# HELP stdatem_commands_total 送信したコマンドの数
+# TYPE stdatem_commands_total counter
+stdatem_commands_total{host="10.0.0.1",action="cut"} 3
+stdatem_commands_total{host="a\"b",action=""} 1
+# HELP stdatem_ack_seconds 反映までの時間
+# TYPE stdatem_ack_seconds histogram
+stdatem_ack_seconds_bucket{host="10.0.0.1",le="0.1"} 1
+stdatem_ack_seconds_bucket{host="10.0.0.1",le="1"} 2
+stdatem_ack_seconds_bucket{host="10.0.0.1",le="+Inf"} 3
+stdatem_ack_seconds_sum{host="10.0.0.1"} 3.55
+stdatem_ack_seconds_count{host="10.0.0.1"} 3
+# HELP stdatem_connected 接続状態
+# TYPE stdatem_connected gauge
+stdatem_connected{host="10.0.0.1"} 1
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteTo =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestServer(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("stdatem_test_total", "テスト").Inc()
+
+	s, err := NewServer(Config{Address: "127.0.0.1:0", Registry: r})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	resp, err := http.Get("http://" + s.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != contentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(string(body), "stdatem_test_total 1\n") {
+		t.Errorf("body = %q", body)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run: %v", err)
+	}
+	if _, err := NewServer(Config{}); err == nil {
+		t.Error("Registryなしで作成できました")
+	}
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// DefaultAddress 待ち受けアドレスの既定値。既定ではローカルホストからのみ接続を受け付ける
+const DefaultAddress = "127.0.0.1:9110"
+
+// Config サーバーの設定
+type Config struct {
+	Address  string // 空の場合はDefaultAddress
+	Registry Registry
+}
+
+// Server GET /metrics でメトリクスを公開するHTTPサーバー
+type Server interface {
+	Addr() net.Addr
+	// Run ctxが終了するかCloseされるまで接続を受け付ける
+	Run(ctx context.Context) error
+	Close() error
+}
+
+// NewServer サーバーを初期化し、ポートをbindする
+func NewServer(cfg Config) (Server, error) {
+	if cfg.Registry == nil {
+		return nil, xerrors.New("Registryが指定されていません")
+	}
+	if cfg.Address == "" {
+		cfg.Address = DefaultAddress
+	}
+
+	listener, err := net.Listen("tcp", cfg.Address)
+	if err != nil {
+		return nil, xerrors.Errorf("HTTPの待ち受けに失敗: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", cfg.Registry.Handler())
+	return &server{
+		listener: listener,
+		httpServer: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}, nil
+}
+
+type server struct {
+	listener   net.Listener
+	httpServer *http.Server
+	closeOnce  sync.Once
+}
+
+func (s *server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+func (s *server) Run(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+	if err := s.httpServer.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return xerrors.Errorf("HTTPサーバーの実行に失敗: %w", err)
+	}
+	return nil
+}
+
+func (s *server) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		err = s.httpServer.Close()
+	})
+	return err
+}
@@ -0,0 +1,178 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// bundleTag OSC Bundleの先頭
+const bundleTag = "#bundle"
+
+// Message OSCメッセージ
+// 引数はint32、float32、string、boolに対応する
+type Message struct {
+	Address string
+	Args    []any
+}
+
+// MarshalBinary OSCメッセージをバイト列にする
+func (m Message) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	writeString(&buf, m.Address)
+
+	tags := strings.Builder{}
+	tags.WriteByte(',')
+	var args bytes.Buffer
+	for _, arg := range m.Args {
+		switch v := arg.(type) {
+		case int32:
+			tags.WriteByte('i')
+			binary.Write(&args, binary.BigEndian, v)
+		case int:
+			tags.WriteByte('i')
+			binary.Write(&args, binary.BigEndian, int32(v))
+		case float32:
+			tags.WriteByte('f')
+			binary.Write(&args, binary.BigEndian, math.Float32bits(v))
+		case float64:
+			tags.WriteByte('f')
+			binary.Write(&args, binary.BigEndian, math.Float32bits(float32(v)))
+		case string:
+			tags.WriteByte('s')
+			writeString(&args, v)
+		case bool:
+			if v {
+				tags.WriteByte('T')
+			} else {
+				tags.WriteByte('F')
+			}
+		default:
+			return nil, xerrors.Errorf("OSCの引数 %T には対応していません", arg)
+		}
+	}
+	writeString(&buf, tags.String())
+	buf.Write(args.Bytes())
+	return buf.Bytes(), nil
+}
+
+// ParsePacket OSCパケットを解析する。Bundleの場合は含まれる全てのメッセージを返す
+func ParsePacket(b []byte) ([]Message, error) {
+	if len(b) == 0 {
+		return nil, xerrors.New("空のOSCパケットです")
+	}
+	if b[0] != '#' {
+		m, err := parseMessage(b)
+		if err != nil {
+			return nil, err
+		}
+		return []Message{m}, nil
+	}
+
+	tag, rest, err := readString(b)
+	if err != nil {
+		return nil, err
+	}
+	if tag != bundleTag {
+		return nil, xerrors.Errorf("不正なOSC Bundleです %q", tag)
+	}
+	if len(rest) < 8 {
+		return nil, xerrors.New("OSC BundleのTime Tagがありません")
+	}
+	rest = rest[8:] // Time Tagは無視して即時に実行する
+	var messages []Message
+	for len(rest) > 0 {
+		if len(rest) < 4 {
+			return nil, xerrors.New("OSC Bundleの要素のサイズがありません")
+		}
+		size := int(binary.BigEndian.Uint32(rest))
+		rest = rest[4:]
+		if size > len(rest) {
+			return nil, xerrors.New("OSC Bundleの要素が途中で終わっています")
+		}
+		elements, err := ParsePacket(rest[:size])
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, elements...)
+		rest = rest[size:]
+	}
+	return messages, nil
+}
+
+func parseMessage(b []byte) (Message, error) {
+	address, rest, err := readString(b)
+	if err != nil {
+		return Message{}, xerrors.Errorf("OSCアドレスの読み込みに失敗: %w", err)
+	}
+	m := Message{Address: address}
+	if len(rest) == 0 {
+		// 古い実装は型タグを省略する
+		return m, nil
+	}
+
+	tags, rest, err := readString(rest)
+	if err != nil {
+		return Message{}, xerrors.Errorf("OSC型タグの読み込みに失敗: %w", err)
+	}
+	if !strings.HasPrefix(tags, ",") {
+		return Message{}, xerrors.Errorf("不正なOSC型タグです %q", tags)
+	}
+	for _, tag := range tags[1:] {
+		switch tag {
+		case 'i':
+			if len(rest) < 4 {
+				return Message{}, xerrors.New("OSCの引数が途中で終わっています")
+			}
+			m.Args = append(m.Args, int32(binary.BigEndian.Uint32(rest)))
+			rest = rest[4:]
+		case 'f':
+			if len(rest) < 4 {
+				return Message{}, xerrors.New("OSCの引数が途中で終わっています")
+			}
+			m.Args = append(m.Args, math.Float32frombits(binary.BigEndian.Uint32(rest)))
+			rest = rest[4:]
+		case 's':
+			var s string
+			s, rest, err = readString(rest)
+			if err != nil {
+				return Message{}, xerrors.Errorf("OSCの文字列引数の読み込みに失敗: %w", err)
+			}
+			m.Args = append(m.Args, s)
+		case 'T':
+			m.Args = append(m.Args, true)
+		case 'F':
+			m.Args = append(m.Args, false)
+		case 'N', 'I':
+			m.Args = append(m.Args, nil)
+		default:
+			return Message{}, xerrors.Errorf("OSCの型 %q には対応していません", tag)
+		}
+	}
+	return m, nil
+}
+
+// writeString NULL終端し、4バイト境界まで埋める
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteString(s)
+	buf.WriteByte(0)
+	for buf.Len()%4 != 0 {
+		buf.WriteByte(0)
+	}
+}
+
+// readString NULL終端の文字列を読み込み、4バイト境界以降の残りを返す
+func readString(b []byte) (string, []byte, error) {
+	end := bytes.IndexByte(b, 0)
+	if end < 0 {
+		return "", nil, xerrors.New("文字列が終端されていません")
+	}
+	next := (end + 4) &^ 3
+	if next > len(b) {
+		next = len(b)
+	}
+	return string(b[:end]), b[next:], nil
+}
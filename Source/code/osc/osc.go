@@ -0,0 +1,327 @@
+// Package osc OSC(UDP)でスイッチャーを操作し、状態の変化をOSCでフィードバックする
+//
+// 受信するアドレス(M/EとDSKの番号は1始まり):
+//
+//	/atem/{switcher}/me/{n}/program {input}
+//	/atem/{switcher}/me/{n}/preview {input}
+//	/atem/{switcher}/me/{n}/cut
+//	/atem/{switcher}/me/{n}/auto
+//	/atem/{switcher}/cut                     M/E 1のCut
+//	/atem/{switcher}/auto                    M/E 1のAuto
+//	/atem/{switcher}/dsk/{n}/onair {0|1}
+//
+// Cut/Autoの引数に0が指定された場合は、ボタンを離した時の送信とみなして無視する。
+//
+// フィードバックするアドレス:
+//
+//	/atem/{switcher}/connected {0|1}
+//	/atem/{switcher}/me/{n}/program {input}
+//	/atem/{switcher}/me/{n}/preview {input}
+//
+// OSCには認証がないため、既定ではローカルホストでのみ待ち受ける。
+package osc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/logger"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"golang.org/x/xerrors"
+)
+
+// DefaultAddress 受信アドレスの既定値
+const DefaultAddress = "127.0.0.1:9000"
+
+// maxPacketSize 受信するOSCパケットの最大サイズ
+const maxPacketSize = 65535
+
+// Controller スイッチャーの操作。名前はグローバル設定の名前付きスイッチャーの名前のみを受け付ける
+type Controller interface {
+	Cut(ctx context.Context, name string, meIndex uint8) error
+	Auto(ctx context.Context, name string, meIndex uint8) error
+	SetProgram(ctx context.Context, name string, meIndex uint8, input uint16) error
+	SetPreview(ctx context.Context, name string, meIndex uint8, input uint16) error
+	SetDSKOnAir(ctx context.Context, name string, index uint8, onAir bool) error
+}
+
+// Config サーバーの設定
+type Config struct {
+	Address    string   // 受信アドレス。空の場合はDefaultAddress
+	Targets    []string // フィードバックの送信先 "host:port"
+	Controller Controller
+	Logger     logger.Logger // nilの場合はログを出力しない
+}
+
+// Server OSCの受信とフィードバックの送信
+type Server interface {
+	Addr() net.Addr
+	// Run ctxが終了するかCloseされるまで受信を続ける
+	Run(ctx context.Context) error
+	Close() error
+	// Watch スイッチャーnameの状態変化をフィードバックする
+	Watch(name string, st state.Switcher) (unwatch func())
+}
+
+// NewServer サーバーを初期化し、ポートをbindする
+func NewServer(cfg Config) (Server, error) {
+	if cfg.Controller == nil {
+		return nil, xerrors.New("Controllerが指定されていません")
+	}
+	if cfg.Address == "" {
+		cfg.Address = DefaultAddress
+	}
+
+	targets := make([]*net.UDPAddr, 0, len(cfg.Targets))
+	for _, t := range cfg.Targets {
+		addr, err := net.ResolveUDPAddr("udp", t)
+		if err != nil {
+			return nil, xerrors.Errorf("フィードバックの送信先 %q の解決に失敗: %w", t, err)
+		}
+		targets = append(targets, addr)
+	}
+
+	conn, err := net.ListenPacket("udp", cfg.Address)
+	if err != nil {
+		return nil, xerrors.Errorf("OSCの待ち受けに失敗: %w", err)
+	}
+	return &server{
+		cfg:     cfg,
+		conn:    conn,
+		targets: targets,
+	}, nil
+}
+
+type server struct {
+	cfg       Config
+	conn      net.PacketConn
+	targets   []*net.UDPAddr
+	closeOnce sync.Once
+}
+
+func (s *server) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+func (s *server) Run(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return xerrors.Errorf("OSCの受信に失敗: %w", err)
+		}
+		messages, err := ParsePacket(buf[:n])
+		if err != nil {
+			s.debug(ctx, "%s からの不正なパケット: %v", addr, err)
+			continue
+		}
+		for _, m := range messages {
+			if err := s.dispatch(ctx, m); err != nil {
+				s.debug(ctx, "%s %v の処理に失敗: %v", m.Address, m.Args, err)
+			}
+		}
+	}
+}
+
+func (s *server) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		err = s.conn.Close()
+	})
+	return err
+}
+
+func (s *server) debug(ctx context.Context, format string, args ...any) {
+	if s.cfg.Logger == nil {
+		return
+	}
+	s.cfg.Logger.Debug(ctx, format, args...)
+}
+
+// dispatch OSCメッセージをスイッチャーの操作に変換する
+func (s *server) dispatch(ctx context.Context, m Message) error {
+	parts := strings.Split(strings.Trim(m.Address, "/"), "/")
+	if len(parts) < 3 || parts[0] != "atem" {
+		return xerrors.Errorf("未対応のアドレスです")
+	}
+	name, rest := parts[1], parts[2:]
+	c := s.cfg.Controller
+
+	switch {
+	case len(rest) == 1 && rest[0] == "cut":
+		if released(m.Args) {
+			return nil
+		}
+		return c.Cut(ctx, name, 0)
+	case len(rest) == 1 && rest[0] == "auto":
+		if released(m.Args) {
+			return nil
+		}
+		return c.Auto(ctx, name, 0)
+	case len(rest) == 3 && rest[0] == "me":
+		meIndex, err := parseIndex(rest[1])
+		if err != nil {
+			return err
+		}
+		switch rest[2] {
+		case "cut":
+			if released(m.Args) {
+				return nil
+			}
+			return c.Cut(ctx, name, meIndex)
+		case "auto":
+			if released(m.Args) {
+				return nil
+			}
+			return c.Auto(ctx, name, meIndex)
+		case "program", "preview":
+			input, ok := intArg(m.Args)
+			if !ok || input < 0 || input > 0xFFFF {
+				return xerrors.New("入力番号が指定されていません")
+			}
+			if rest[2] == "program" {
+				return c.SetProgram(ctx, name, meIndex, uint16(input))
+			}
+			return c.SetPreview(ctx, name, meIndex, uint16(input))
+		}
+	case len(rest) == 3 && rest[0] == "dsk" && rest[2] == "onair":
+		index, err := parseIndex(rest[1])
+		if err != nil {
+			return err
+		}
+		onAir, ok := boolArg(m.Args)
+		if !ok {
+			return xerrors.New("OnAirの値が指定されていません")
+		}
+		return c.SetDSKOnAir(ctx, name, index, onAir)
+	}
+	return xerrors.Errorf("未対応のアドレスです")
+}
+
+// parseIndex 1始まりの番号を0始まりのインデックスにする
+func parseIndex(s string) (uint8, error) {
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil || n == 0 {
+		return 0, xerrors.Errorf("番号 %q が不正です", s)
+	}
+	return uint8(n - 1), nil
+}
+
+func intArg(args []any) (int, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	switch v := args[0].(type) {
+	case int32:
+		return int(v), true
+	case float32:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func boolArg(args []any) (bool, bool) {
+	if len(args) == 0 {
+		return false, false
+	}
+	switch v := args[0].(type) {
+	case bool:
+		return v, true
+	case int32:
+		return v != 0, true
+	case float32:
+		return v >= 0.5, true
+	}
+	return false, false
+}
+
+// released トリガーの引数が0の場合にtrueを返す
+func released(args []any) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch v := args[0].(type) {
+	case int32:
+		return v == 0
+	case float32:
+		return v == 0
+	case bool:
+		return !v
+	}
+	return false
+}
+
+func (s *server) Watch(name string, st state.Switcher) func() {
+	if len(s.targets) == 0 {
+		return func() {}
+	}
+	prefix := "/atem/" + name
+
+	// 現在の状態を送信してから変化を購読する
+	snapshot := st.Snapshot()
+	s.send(Message{Address: prefix + "/connected", Args: []any{boolInt(snapshot.Connected)}})
+	for meIndex, me := range snapshot.MixEffects {
+		s.send(Message{Address: meAddress(prefix, meIndex, "program"), Args: []any{int32(me.Program)}})
+		s.send(Message{Address: meAddress(prefix, meIndex, "preview"), Args: []any{int32(me.Preview)}})
+	}
+
+	return st.Subscribe(func(ev state.Event) {
+		if m, ok := feedback(prefix, ev); ok {
+			s.send(m)
+		}
+	}, state.EventConnection, state.EventProgram, state.EventPreview)
+}
+
+// feedback 状態変化をフィードバックのOSCメッセージにする
+func feedback(prefix string, ev state.Event) (Message, bool) {
+	switch ev := ev.(type) {
+	case state.ConnectionChanged:
+		return Message{Address: prefix + "/connected", Args: []any{boolInt(ev.Connected)}}, true
+	case state.ProgramChanged:
+		return Message{Address: meAddress(prefix, ev.MeIndex, "program"), Args: []any{int32(ev.Input)}}, true
+	case state.PreviewChanged:
+		return Message{Address: meAddress(prefix, ev.MeIndex, "preview"), Args: []any{int32(ev.Input)}}, true
+	}
+	return Message{}, false
+}
+
+func meAddress(prefix string, meIndex uint8, name string) string {
+	return fmt.Sprintf("%s/me/%d/%s", prefix, meIndex+1, name)
+}
+
+func boolInt(v bool) int32 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// send フィードバックを全ての送信先に送る
+func (s *server) send(m Message) {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		s.debug(context.Background(), "%s のマーシャルに失敗: %v", m.Address, err)
+		return
+	}
+	for _, addr := range s.targets {
+		if _, err := s.conn.WriteTo(b, addr); err != nil {
+			s.debug(context.Background(), "%s への送信に失敗: %v", addr, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package profile プラグインの設定(ボタンの割り当てとスイッチャー定義)のエクスポート/インポート
+package profile
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+	"golang.org/x/xerrors"
+)
+
+// Version エクスポートファイルのフォーマットバージョン
+const Version = 1
+
+// Button ボタン1つ分の割り当て
+type Button struct {
+	Action      string              `json:"action"`
+	Device      string              `json:"device"`
+	Coordinates setting.Coordinates `json:"coordinates"`
+	Settings    json.RawMessage     `json:"settings"`
+}
+
+// Profile エクスポートファイルの内容
+type Profile struct {
+	Version    int                       `json:"version"`
+	ExportedAt time.Time                 `json:"exportedAt"`
+	Switchers  []setting.SwitcherProfile `json:"switchers"`
+	Buttons    []Button                  `json:"buttons"`
+}
+
+// Save Profileをpathに書き出す
+func Save(path string, p *Profile) error {
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("プロファイルのマーシャルに失敗: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return xerrors.Errorf("ディレクトリの作成に失敗: %w", err)
+	}
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return xerrors.Errorf("プロファイルの書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+// Load pathからProfileを読み込む
+func Load(path string) (*Profile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("プロファイルの読み込みに失敗: %w", err)
+	}
+	p := &Profile{}
+	if err := json.Unmarshal(b, p); err != nil {
+		return nil, xerrors.Errorf("プロファイルのアンマーシャルに失敗: %w", err)
+	}
+	if p.Version > Version {
+		return nil, xerrors.Errorf("未対応のプロファイルバージョン %d です", p.Version)
+	}
+	return p, nil
+}
+
+// Remap スイッチャーのアドレスとボタンごとのIPをaddressesに従って置き換えたProfileを返す
+// ポートが指定されている場合はホスト部分のみを置き換える
+func (p *Profile) Remap(addresses map[string]string) (*Profile, error) {
+	remapped := &Profile{
+		Version:    p.Version,
+		ExportedAt: p.ExportedAt,
+		Switchers:  make([]setting.SwitcherProfile, 0, len(p.Switchers)),
+		Buttons:    make([]Button, 0, len(p.Buttons)),
+	}
+
+	for _, s := range p.Switchers {
+		s.Address = remapHost(s.Address, addresses)
+		remapped.Switchers = append(remapped.Switchers, s)
+	}
+
+	for _, b := range p.Buttons {
+		if len(b.Settings) > 0 && string(b.Settings) != "null" {
+			settings := map[string]any{}
+			if err := json.Unmarshal(b.Settings, &settings); err != nil {
+				return nil, xerrors.Errorf("ボタン設定のアンマーシャルに失敗: %w", err)
+			}
+			if ip, ok := settings["ip"].(string); ok {
+				settings["ip"] = remapHost(ip, addresses)
+			}
+			raw, err := json.Marshal(settings)
+			if err != nil {
+				return nil, xerrors.Errorf("ボタン設定のマーシャルに失敗: %w", err)
+			}
+			b.Settings = raw
+		}
+		remapped.Buttons = append(remapped.Buttons, b)
+	}
+	return remapped, nil
+}
+
+// FindButton 同じアクションと位置のボタンを検索する。同じデバイスのボタンを優先する
+func (p *Profile) FindButton(action, device string, coordinates setting.Coordinates) (Button, bool) {
+	var found Button
+	ok := false
+	for _, b := range p.Buttons {
+		if b.Action != action || b.Coordinates != coordinates {
+			continue
+		}
+		if b.Device == device {
+			return b, true
+		}
+		if !ok {
+			found, ok = b, true
+		}
+	}
+	return found, ok
+}
+
+// remapHost "address"または"address:port"のアドレス部分を置き換える
+func remapHost(host string, addresses map[string]string) string {
+	if to, ok := addresses[host]; ok {
+		return to
+	}
+	address, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return host
+	}
+	if to, ok := addresses[address]; ok {
+		return net.JoinHostPort(to, port)
+	}
+	return host
+}
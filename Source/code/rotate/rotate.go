@@ -0,0 +1,202 @@
+// Package rotate サイズと日付でファイルを切り替え、古いファイルを削除するio.Writer
+package rotate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// timeLayout ファイル名に含める作成日時
+const timeLayout = "20060102-150405"
+
+// Config ファイルの切り替えの設定
+type Config struct {
+	Dir      string        // 出力先ディレクトリ。存在しない場合は作成する
+	Name     string        // ファイル名の接頭辞
+	Ext      string        // 拡張子 ".log"など
+	MaxSize  int64         // 1ファイルの最大バイト数。0の場合はサイズで切り替えない
+	Daily    bool          // 日付が変わった場合に切り替える
+	MaxAge   time.Duration // これより古いファイルを削除する。0の場合は削除しない
+	MaxFiles int           // 残すファイルの数。0の場合は数で削除しない
+}
+
+// Writer 切り替えながら書き込むio.WriteCloser。並行して利用できる
+type Writer interface {
+	io.WriteCloser
+	// Rotate 現在のファイルを閉じ、新しいファイルに切り替える
+	Rotate() error
+	// Path 現在書き込んでいるファイルのパス
+	Path() string
+}
+
+// NewWriter Writerを初期化し、最初のファイルを作成する
+func NewWriter(cfg Config) (Writer, error) {
+	if cfg.Name == "" {
+		return nil, xerrors.New("ファイル名が指定されていません")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, xerrors.Errorf("ディレクトリの作成に失敗: %w", err)
+	}
+	w := &writer{cfg: cfg}
+	if err := w.Rotate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+type writer struct {
+	cfg Config
+
+	mu     sync.Mutex // 以下を保護する
+	file   *os.File
+	path   string
+	size   int64
+	opened time.Time
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, xerrors.New("ファイルは閉じられています")
+	}
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// shouldRotate 書き込み前に切り替えが必要かどうか
+func (w *writer) shouldRotate(next int64) bool {
+	if w.cfg.MaxSize > 0 && w.size > 0 && w.size+next > w.cfg.MaxSize {
+		return true
+	}
+	if w.cfg.Daily {
+		y1, m1, d1 := w.opened.Date()
+		y2, m2, d2 := time.Now().Date()
+		return y1 != y2 || m1 != m2 || d1 != d2
+	}
+	return false
+}
+
+func (w *writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate()
+}
+
+func (w *writer) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return xerrors.Errorf("ファイルのクローズに失敗: %w", err)
+		}
+		w.file = nil
+	}
+
+	now := time.Now()
+	path := w.nextPath(now)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return xerrors.Errorf("ファイルの作成に失敗: %w", err)
+	}
+	w.file = file
+	w.path = path
+	w.size = 0
+	w.opened = now
+
+	w.cleanup(now)
+	return nil
+}
+
+// nextPath 作成日時からファイル名を決める。同じ名前のファイルが存在する場合は連番を付ける
+func (w *writer) nextPath(now time.Time) string {
+	base := filepath.Join(w.cfg.Dir, w.cfg.Name+"-"+now.Format(timeLayout))
+	path := base + w.cfg.Ext
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s-%d%s", base, i, w.cfg.Ext)
+	}
+}
+
+// cleanup 古いファイルを削除する。現在のファイルは削除しない
+func (w *writer) cleanup(now time.Time) {
+	if w.cfg.MaxAge <= 0 && w.cfg.MaxFiles <= 0 {
+		return
+	}
+	files, err := w.files()
+	if err != nil {
+		return
+	}
+
+	for i, f := range files {
+		if f.path == w.path {
+			continue
+		}
+		tooOld := w.cfg.MaxAge > 0 && now.Sub(f.modTime) > w.cfg.MaxAge
+		tooMany := w.cfg.MaxFiles > 0 && len(files)-i > w.cfg.MaxFiles
+		if tooOld || tooMany {
+			os.Remove(f.path)
+		}
+	}
+}
+
+type fileInfo struct {
+	path    string
+	modTime time.Time
+}
+
+// files このWriterが作成したファイルを古い順に返す
+func (w *writer) files() ([]fileInfo, error) {
+	entries, err := os.ReadDir(w.cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []fileInfo
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, w.cfg.Name+"-") || !strings.HasSuffix(name, w.cfg.Ext) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, fileInfo{path: filepath.Join(w.cfg.Dir, name), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	return files, nil
+}
+
+func (w *writer) Path() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.path
+}
+
+func (w *writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
@@ -0,0 +1,122 @@
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countFiles dirにあるファイルの数
+func countFiles(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	return len(entries)
+}
+
+func TestWriterMaxSize(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	w, err := NewWriter(Config{Dir: dir, Name: "plugin", Ext: ".log", MaxSize: 10})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	defer w.Close()
+
+	first := w.Path()
+	if _, err := w.Write([]byte("12345678")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if w.Path() != first {
+		t.Fatal("MaxSize以下で切り替わりました")
+	}
+	if _, err := w.Write([]byte("12345678")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if w.Path() == first {
+		t.Fatal("MaxSizeを超えても切り替わりません")
+	}
+
+	for path, want := range map[string]string{first: "12345678", w.Path(): "12345678"} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestWriterCleanup(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(Config{Dir: dir, Name: "plugin", Ext: ".log", MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	defer w.Close()
+
+	// 作成順を更新日時で判定するため、切り替え前のファイルを古い日時にする
+	old := time.Now().Add(-time.Hour)
+	for i := 0; i < 4; i++ {
+		modTime := old.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(w.Path(), modTime, modTime); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+		if err := w.Rotate(); err != nil {
+			t.Fatalf("Rotate: %v", err)
+		}
+	}
+	if got := countFiles(t, dir); got != 2 {
+		t.Errorf("ファイル数 = %d, want 2", got)
+	}
+	if _, err := os.Stat(w.Path()); err != nil {
+		t.Errorf("現在のファイルが削除されました: %v", err)
+	}
+
+	// 他のファイルは削除しない
+	other := filepath.Join(dir, "other.txt")
+	os.WriteFile(other, nil, 0644)
+	w.Rotate()
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("対象外のファイルが削除されました: %v", err)
+	}
+}
+
+func TestWriterMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(Config{Dir: dir, Name: "plugin", Ext: ".log", MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	defer w.Close()
+
+	expired := w.Path()
+	old := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(expired, old, old)
+	if err := w.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Errorf("MaxAgeを過ぎたファイルが残っています: %v", err)
+	}
+	if got := countFiles(t, dir); got != 1 {
+		t.Errorf("ファイル数 = %d, want 1", got)
+	}
+}
+
+func TestWriterClosed(t *testing.T) {
+	w, err := NewWriter(Config{Dir: t.TempDir(), Name: "plugin", Ext: ".log"})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	w.Close()
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Error("閉じた後に書き込めました")
+	}
+	if _, err := NewWriter(Config{Dir: t.TempDir()}); err == nil {
+		t.Error("ファイル名なしで作成できました")
+	}
+}
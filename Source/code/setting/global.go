@@ -0,0 +1,206 @@
+package setting
+
+import (
+	"net"
+	"strconv"
+)
+
+// DefaultATEMPort ATEMの標準ポート
+const DefaultATEMPort = 9910
+
+// GlobalSettings プラグイン全体で共有するグローバル設定
+type GlobalSettings struct {
+	Switchers []SwitcherProfile `json:"switchers"`
+	VMix      *VMixSettings     `json:"vmix,omitempty"`
+	TSL       *TSLSettings      `json:"tsl,omitempty"`
+	API       *APISettings      `json:"api,omitempty"`
+	OSC       *OSCSettings      `json:"osc,omitempty"`
+	AsRun     *AsRunSettings    `json:"asrun,omitempty"`
+	Log       *LogSettings      `json:"log,omitempty"`
+	Metrics   *MetricsSettings  `json:"metrics,omitempty"`
+	Lock      *LockSettings     `json:"lock,omitempty"`
+}
+
+// SwitcherProfile 名前付きスイッチャーの接続先
+type SwitcherProfile struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Port    int    `json:"port,omitempty"`
+}
+
+// Host 接続に利用するホスト文字列。標準ポートの場合はアドレスのみを返す
+func (p SwitcherProfile) Host() string {
+	if p.Port == 0 || p.Port == DefaultATEMPort {
+		return p.Address
+	}
+	return net.JoinHostPort(p.Address, strconv.Itoa(p.Port))
+}
+
+// Switcher 名前からスイッチャーを検索する
+func (g *GlobalSettings) Switcher(name string) (SwitcherProfile, bool) {
+	if g == nil {
+		return SwitcherProfile{}, false
+	}
+	for _, s := range g.Switchers {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return SwitcherProfile{}, false
+}
+
+// VMixSettings vMix互換タリーサーバーの設定
+type VMixSettings struct {
+	Enabled     bool   `json:"enabled"`
+	Switcher    string `json:"switcher"` // 名前付きスイッチャーまたはATEMのアドレス
+	MeIndex     int    `json:"meIndex,omitempty"`
+	Inputs      int    `json:"inputs,omitempty"`
+	TCPAddress  string `json:"tcpAddress,omitempty"`
+	HTTPAddress string `json:"httpAddress,omitempty"`
+}
+
+// VMixSettings vMix互換タリーサーバーの設定。未設定の場合は無効な設定を返す
+func (g *GlobalSettings) VMixSettings() VMixSettings {
+	if g == nil || g.VMix == nil {
+		return VMixSettings{}
+	}
+	return *g.VMix
+}
+
+// TSLSettings TSL UMD送信の設定
+type TSLSettings struct {
+	Enabled       bool     `json:"enabled"`
+	Switcher      string   `json:"switcher"` // 名前付きスイッチャーまたはATEMのアドレス
+	MeIndex       int      `json:"meIndex,omitempty"`
+	Inputs        int      `json:"inputs,omitempty"`
+	AddressOffset int      `json:"addressOffset,omitempty"`
+	Destinations  []string `json:"destinations"` // "udp://host:port?version=3.1"の形式
+}
+
+// TSLSettings TSL UMD送信の設定。未設定の場合は無効な設定を返す
+func (g *GlobalSettings) TSLSettings() TSLSettings {
+	if g == nil || g.TSL == nil {
+		return TSLSettings{}
+	}
+	return *g.TSL
+}
+
+// APISettings ローカルHTTP APIの設定
+type APISettings struct {
+	Enabled        bool     `json:"enabled"`
+	Address        string   `json:"address,omitempty"`        // 待ち受けアドレス。空の場合はローカルホストのみ
+	Token          string   `json:"token,omitempty"`          // 空の場合は起動時に生成して保存する
+	AllowedOrigins []string `json:"allowedOrigins,omitempty"` // ブラウザから接続を許可するOriginのホスト。"*.example.com"の形式で指定できる
+}
+
+// APISettings ローカルHTTP APIの設定。未設定の場合は無効な設定を返す
+func (g *GlobalSettings) APISettings() APISettings {
+	if g == nil || g.API == nil {
+		return APISettings{}
+	}
+	return *g.API
+}
+
+// OSCSettings OSCの受信とフィードバックの設定
+type OSCSettings struct {
+	Enabled  bool     `json:"enabled"`
+	Address  string   `json:"address,omitempty"`  // 受信アドレス。空の場合はローカルホストのみ
+	Targets  []string `json:"targets,omitempty"`  // フィードバックの送信先 "host:port"
+	Feedback []string `json:"feedback,omitempty"` // フィードバックするスイッチャー。空の場合は全ての名前付きスイッチャー
+}
+
+// OSCSettings OSCの設定。未設定の場合は無効な設定を返す
+func (g *GlobalSettings) OSCSettings() OSCSettings {
+	if g == nil || g.OSC == nil {
+		return OSCSettings{}
+	}
+	return *g.OSC
+}
+
+// AsRunSettings As-Runログの設定
+type AsRunSettings struct {
+	Enabled    bool   `json:"enabled"`
+	Dir        string `json:"dir,omitempty"`        // 出力先ディレクトリ
+	MaxSizeMB  int    `json:"maxSizeMB,omitempty"`  // 1ファイルの最大サイズ(MB)
+	MaxAgeDays int    `json:"maxAgeDays,omitempty"` // 保持する日数。0の場合は削除しない
+}
+
+// AsRunSettings As-Runログの設定。未設定の場合は無効な設定を返す
+func (g *GlobalSettings) AsRunSettings() AsRunSettings {
+	if g == nil || g.AsRun == nil {
+		return AsRunSettings{}
+	}
+	return *g.AsRun
+}
+
+// LogSettings プラグインのログの設定
+type LogSettings struct {
+	Level string `json:"level,omitempty"` // "debug"、"info"、"warn"、"error"。空の場合は"info"
+}
+
+// LogSettings プラグインのログの設定。未設定の場合は既定の設定を返す
+func (g *GlobalSettings) LogSettings() LogSettings {
+	if g == nil || g.Log == nil {
+		return LogSettings{}
+	}
+	return *g.Log
+}
+
+// MetricsSettings Prometheus形式のメトリクスの公開の設定
+type MetricsSettings struct {
+	Enabled bool   `json:"enabled"`
+	Address string `json:"address,omitempty"` // 待ち受けアドレス。空の場合は"127.0.0.1:9110"
+}
+
+// MetricsSettings メトリクスの公開の設定。未設定の場合は無効な設定を返す
+func (g *GlobalSettings) MetricsSettings() MetricsSettings {
+	if g == nil || g.Metrics == nil {
+		return MetricsSettings{}
+	}
+	return *g.Metrics
+}
+
+// LockMode パネルロックの状態
+type LockMode string
+
+const (
+	// LockModeOff ボタンからの操作をそのまま送信する
+	LockModeOff LockMode = ""
+	// LockModeLocked スイッチャーを操作するボタンを無効にする
+	LockModeLocked LockMode = "locked"
+	// LockModeRehearsal 操作をリハーサル用のスイッチャーにのみ送信する
+	LockModeRehearsal LockMode = "rehearsal"
+)
+
+// LockSettings パネルロック・リハーサルモードの設定
+type LockSettings struct {
+	Mode LockMode `json:"mode,omitempty"`
+	// Rehearsal リハーサル中の送信先。名前付きスイッチャーの名前かATEMのアドレス
+	// 空の場合はプラグイン内でシミュレーターを起動する
+	Rehearsal string `json:"rehearsal,omitempty"`
+}
+
+// LockSettings パネルロックの設定。未設定の場合はロックしない設定を返す
+func (g *GlobalSettings) LockSettings() LockSettings {
+	if g == nil || g.Lock == nil {
+		return LockSettings{}
+	}
+	return *g.Lock
+}
+
+// redacted 診断データなどで秘匿情報を置き換える文字列
+const redacted = "********"
+
+// Sanitized 秘匿情報(APIトークン)を取り除いたコピーを返す
+func (g *GlobalSettings) Sanitized() GlobalSettings {
+	if g == nil {
+		return GlobalSettings{}
+	}
+	s := *g
+	if g.API != nil && g.API.Token != "" {
+		api := *g.API
+		api.Token = redacted
+		s.API = &api
+	}
+	return s
+}
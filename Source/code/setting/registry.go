@@ -0,0 +1,103 @@
+package setting
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"github.com/puzpuzpuz/xsync/v3"
+)
+
+// Coordinates ボタンの位置
+type Coordinates struct {
+	Column int `json:"column"`
+	Row    int `json:"row"`
+}
+
+// Entry Context(ボタン)ごとの登録情報
+type Entry struct {
+	Context     string
+	Action      string // アクションUUID
+	Device      string
+	Coordinates Coordinates
+
+	Settings    any             // アクションごとの解析済みの設定
+	RawSettings json.RawMessage // Stream Deckに保存されている設定
+	Events      []state.EventType
+
+	Switcher string // 名前付きスイッチャー。空の場合はIPを直接利用する
+	IP       string // ボタンごとに設定されたIP
+	Host     string // 実際に接続しているホスト。未解決の場合は空
+
+	Title string // ユーザーが設定したタイトル。ロック表示の解除時に元に戻す
+}
+
+// Wants 描画にイベントtが必要かどうか
+func (e Entry) Wants(t state.EventType) bool {
+	return slices.Contains(e.Events, t)
+}
+
+// Registry 全てのアクションの設定をContextごとに保持する
+type Registry interface {
+	Load(contextID string) (Entry, bool)
+	Store(entry Entry)
+	// Update 登録済みのEntryを更新する。未登録の場合はfalseを返す
+	Update(contextID string, f func(e *Entry)) bool
+	Delete(contextID string)
+	Range(f func(e Entry) bool)
+	// Subscribers ホストhostに接続していて、イベントtを必要とするEntry
+	Subscribers(host string, t state.EventType) []Entry
+}
+
+// NewRegistry Registryを初期化する
+func NewRegistry() Registry {
+	return &registry{
+		m: xsync.NewMapOf[string, Entry](),
+	}
+}
+
+type registry struct {
+	m *xsync.MapOf[string, Entry]
+}
+
+func (r *registry) Load(contextID string) (Entry, bool) {
+	return r.m.Load(contextID)
+}
+
+func (r *registry) Store(entry Entry) {
+	r.m.Store(entry.Context, entry)
+}
+
+func (r *registry) Update(contextID string, f func(e *Entry)) bool {
+	updated := false
+	r.m.Compute(contextID, func(e Entry, loaded bool) (Entry, bool) {
+		if !loaded {
+			return e, true
+		}
+		f(&e)
+		updated = true
+		return e, false
+	})
+	return updated
+}
+
+func (r *registry) Delete(contextID string) {
+	r.m.Delete(contextID)
+}
+
+func (r *registry) Range(f func(e Entry) bool) {
+	r.m.Range(func(_ string, e Entry) bool {
+		return f(e)
+	})
+}
+
+func (r *registry) Subscribers(host string, t state.EventType) []Entry {
+	var entries []Entry
+	r.m.Range(func(_ string, e Entry) bool {
+		if e.Host == host && e.Wants(t) {
+			entries = append(entries, e)
+		}
+		return true
+	})
+	return entries
+}
@@ -0,0 +1,127 @@
+package state
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/puzpuzpuz/xsync/v3"
+)
+
+// subscriberBufferSize 購読者ごとのイベントバッファ長
+const subscriberBufferSize = 64
+
+// Handler イベントを受け取るコールバック
+type Handler func(ev Event)
+
+// Bus 状態変化イベントのpublish/subscribe
+type Bus interface {
+	// Subscribe typesに一致するイベントを購読する。typesが空の場合は全てのイベントを購読する
+	Subscribe(handler Handler, types ...EventType) (unsubscribe func())
+	Publish(ev Event)
+	// Dropped バッファ溢れで破棄されたイベントの数
+	Dropped() uint64
+	Close()
+}
+
+// NewBus Busを初期化する
+func NewBus() Bus {
+	return &bus{
+		subscribers: xsync.NewMapOf[uint64, *subscriber](),
+	}
+}
+
+type bus struct {
+	subscribers *xsync.MapOf[uint64, *subscriber]
+	nextID      atomic.Uint64
+	dropped     atomic.Uint64
+}
+
+type subscriber struct {
+	types map[EventType]struct{}
+	ch    chan Event
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func (s *subscriber) wants(t EventType) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+	_, ok := s.types[t]
+	return ok
+}
+
+// send 購読者のバッファにイベントを積む。バッファが溢れている場合はfalseを返す
+func (s *subscriber) send(ev Event) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return true
+	}
+	select {
+	case s.ch <- ev:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *subscriber) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.ch)
+}
+
+func (b *bus) Subscribe(handler Handler, types ...EventType) func() {
+	sub := &subscriber{
+		types: make(map[EventType]struct{}, len(types)),
+		ch:    make(chan Event, subscriberBufferSize),
+	}
+	for _, t := range types {
+		sub.types[t] = struct{}{}
+	}
+
+	id := b.nextID.Add(1)
+	b.subscribers.Store(id, sub)
+
+	// 購読者ごとに順序を保ったまま配送する
+	go func() {
+		for ev := range sub.ch {
+			handler(ev)
+		}
+	}()
+
+	return func() {
+		b.subscribers.Delete(id)
+		sub.close()
+	}
+}
+
+func (b *bus) Publish(ev Event) {
+	b.subscribers.Range(func(_ uint64, sub *subscriber) bool {
+		if !sub.wants(ev.Type()) {
+			return true
+		}
+		if !sub.send(ev) {
+			b.dropped.Add(1)
+		}
+		return true
+	})
+}
+
+func (b *bus) Dropped() uint64 {
+	return b.dropped.Load()
+}
+
+func (b *bus) Close() {
+	b.subscribers.Range(func(id uint64, sub *subscriber) bool {
+		b.subscribers.Delete(id)
+		sub.close()
+		return true
+	})
+}
@@ -0,0 +1,47 @@
+package state
+
+// EventType 状態変化イベントの種類
+type EventType string
+
+const (
+	EventConnection EventType = "connection"
+	EventProgram    EventType = "program"
+	EventPreview    EventType = "preview"
+	EventInput      EventType = "input"
+)
+
+// Event スイッチャーの状態変化イベント
+type Event interface {
+	Type() EventType
+}
+
+// ConnectionChanged 接続状態の変化
+type ConnectionChanged struct {
+	Connected bool `json:"connected"`
+}
+
+// ProgramChanged PGMバスの変化
+type ProgramChanged struct {
+	MeIndex  uint8  `json:"meIndex"`
+	Input    uint16 `json:"input"`
+	Previous uint16 `json:"previous"`
+}
+
+// PreviewChanged PVWバスの変化
+type PreviewChanged struct {
+	MeIndex  uint8  `json:"meIndex"`
+	Input    uint16 `json:"input"`
+	Previous uint16 `json:"previous"`
+}
+
+// InputChanged 入力ソースの名前の変化
+type InputChanged struct {
+	Input     uint16 `json:"input"`
+	LongName  string `json:"longName"`
+	ShortName string `json:"shortName"`
+}
+
+func (ConnectionChanged) Type() EventType { return EventConnection }
+func (ProgramChanged) Type() EventType    { return EventProgram }
+func (PreviewChanged) Type() EventType    { return EventPreview }
+func (InputChanged) Type() EventType      { return EventInput }
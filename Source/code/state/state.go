@@ -0,0 +1,185 @@
+package state
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/puzpuzpuz/xsync/v3"
+)
+
+// MixEffect M/Eごとの状態
+type MixEffect struct {
+	Program uint16 `json:"program"`
+	Preview uint16 `json:"preview"`
+}
+
+// Input 入力ソースの名前
+type Input struct {
+	Input     uint16 `json:"input"`
+	LongName  string `json:"longName"`
+	ShortName string `json:"shortName"`
+}
+
+// InputName 入力ソースの名前を返す。スイッチャーから名前を受信していない場合は番号から生成する
+func InputName(st Switcher, input uint16) Input {
+	if st != nil {
+		if in, ok := st.Input(input); ok {
+			return in
+		}
+	}
+	return Input{
+		Input:     input,
+		LongName:  fmt.Sprintf("Input %d", input),
+		ShortName: fmt.Sprintf("IN%d", input),
+	}
+}
+
+// Snapshot ある時点のスイッチャー状態のコピー
+type Snapshot struct {
+	Connected  bool                `json:"connected"`
+	MixEffects map[uint8]MixEffect `json:"mixEffects"`
+	Inputs     map[uint16]Input    `json:"inputs"`
+}
+
+// Switcher スイッチャー1台分の状態キャッシュ
+// ATEMクライアントが受信できる接続状態、PGM/PVW、入力ソースの名前を保持する
+// 更新系のメソッドは値が変化した場合のみイベントをpublishする。イベントは更新と同じ順序でpublishされる
+type Switcher interface {
+	Bus
+
+	Snapshot() Snapshot
+	Connected() bool
+	MixEffect(meIndex uint8) (MixEffect, bool)
+	Program(meIndex uint8) (uint16, bool)
+	Preview(meIndex uint8) (uint16, bool)
+	Input(input uint16) (Input, bool)
+
+	SetConnected(connected bool)
+	SetProgram(meIndex uint8, input uint16)
+	SetPreview(meIndex uint8, input uint16)
+	SetInputName(input uint16, longName, shortName string)
+}
+
+// NewSwitcher Switcherを初期化する
+func NewSwitcher() Switcher {
+	return &switcher{
+		Bus:        NewBus(),
+		mixEffects: xsync.NewMapOf[uint8, MixEffect](),
+		inputs:     xsync.NewMapOf[uint16, Input](),
+	}
+}
+
+type switcher struct {
+	Bus
+
+	mixEffects *xsync.MapOf[uint8, MixEffect]
+	inputs     *xsync.MapOf[uint16, Input]
+
+	// mu 更新とpublishを直列化する。並行して更新された場合も、イベントの順序とPreviousを状態の変化と一致させる
+	// publishは購読者のバッファに積むだけでブロックしないため、ロック中に呼び出す
+	mu        sync.Mutex
+	connected bool
+}
+
+func (s *switcher) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := Snapshot{
+		Connected:  s.connected,
+		MixEffects: make(map[uint8]MixEffect),
+		Inputs:     make(map[uint16]Input),
+	}
+	s.mixEffects.Range(func(k uint8, v MixEffect) bool {
+		snapshot.MixEffects[k] = v
+		return true
+	})
+	s.inputs.Range(func(k uint16, v Input) bool {
+		snapshot.Inputs[k] = v
+		return true
+	})
+	return snapshot
+}
+
+func (s *switcher) Connected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connected
+}
+
+func (s *switcher) MixEffect(meIndex uint8) (MixEffect, bool) {
+	return s.mixEffects.Load(meIndex)
+}
+
+func (s *switcher) Program(meIndex uint8) (uint16, bool) {
+	me, ok := s.mixEffects.Load(meIndex)
+	return me.Program, ok
+}
+
+func (s *switcher) Preview(meIndex uint8) (uint16, bool) {
+	me, ok := s.mixEffects.Load(meIndex)
+	return me.Preview, ok
+}
+
+func (s *switcher) Input(input uint16) (Input, bool) {
+	return s.inputs.Load(input)
+}
+
+func (s *switcher) SetConnected(connected bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.connected == connected {
+		return
+	}
+	s.connected = connected
+	s.Publish(ConnectionChanged{Connected: connected})
+}
+
+// updateMixEffect M/Eの状態を更新し、変化したかどうかを返す。s.muを保持して呼び出す
+func (s *switcher) updateMixEffect(meIndex uint8, f func(me *MixEffect)) (before MixEffect, changed bool) {
+	before, loaded := s.mixEffects.Load(meIndex)
+	after := before
+	f(&after)
+	if loaded && before == after {
+		return before, false
+	}
+	s.mixEffects.Store(meIndex, after)
+	return before, true
+}
+
+func (s *switcher) SetProgram(meIndex uint8, input uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, changed := s.updateMixEffect(meIndex, func(me *MixEffect) {
+		me.Program = input
+	})
+	if changed {
+		s.Publish(ProgramChanged{MeIndex: meIndex, Input: input, Previous: before.Program})
+	}
+}
+
+func (s *switcher) SetPreview(meIndex uint8, input uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, changed := s.updateMixEffect(meIndex, func(me *MixEffect) {
+		me.Preview = input
+	})
+	if changed {
+		s.Publish(PreviewChanged{MeIndex: meIndex, Input: input, Previous: before.Preview})
+	}
+}
+
+func (s *switcher) SetInputName(input uint16, longName, shortName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := Input{Input: input, LongName: longName, ShortName: shortName}
+	if prev, loaded := s.inputs.Load(input); loaded && prev == next {
+		return
+	}
+	s.inputs.Store(input, next)
+	s.Publish(InputChanged{Input: input, LongName: longName, ShortName: shortName})
+}
@@ -0,0 +1,153 @@
+package stdatem
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"github.com/FlowingSPDG/streamdeck"
+	sdcontext "github.com/FlowingSPDG/streamdeck/context"
+)
+
+// ackTimeout 送信した操作の反映をこの時間だけ待つ
+const ackTimeout = 5 * time.Second
+
+// ackEvents 操作の反映と切断を検知するために購読する状態変化
+var ackEvents = []state.EventType{
+	state.EventConnection,
+	state.EventProgram,
+	state.EventPreview,
+}
+
+// ackMatcher 状態変化が送信した操作の反映であればtrueを返す
+type ackMatcher func(ev state.Event) bool
+
+// pendingAck 反映を待っている操作
+type pendingAck struct {
+	host   string
+	action string
+	match  ackMatcher
+	sentAt time.Time
+	timer  *time.Timer
+	done   atomic.Bool
+}
+
+// expectProgram PGMがinputになったことを待つ。既にPGMにある場合はnilを返す
+func expectProgram(st state.Switcher, meIndex uint8, input uint16) ackMatcher {
+	if program, ok := st.Program(meIndex); ok && program == input {
+		return nil
+	}
+	return func(ev state.Event) bool {
+		e, ok := ev.(state.ProgramChanged)
+		return ok && e.MeIndex == meIndex && e.Input == input
+	}
+}
+
+// expectPreview PVWがinputになったことを待つ。既にPVWにある場合はnilを返す
+func expectPreview(st state.Switcher, meIndex uint8, input uint16) ackMatcher {
+	if preview, ok := st.Preview(meIndex); ok && preview == input {
+		return nil
+	}
+	return func(ev state.Event) bool {
+		e, ok := ev.(state.PreviewChanged)
+		return ok && e.MeIndex == meIndex && e.Input == input
+	}
+}
+
+// expectCut PGMとPVWの入れ替えによるPGMの切り替えを待つ
+// PGMとPVWが同じ入力の場合はPGMが変化しないため、nilを返す
+// ATEMクライアントはトランジションの状態を通知しないため、AutoもトランジションがPGMを切り替えるまで待つ
+func expectCut(st state.Switcher, meIndex uint8) ackMatcher {
+	if me, ok := st.MixEffect(meIndex); ok && me.Program == me.Preview {
+		return nil
+	}
+	return func(ev state.Event) bool {
+		e, ok := ev.(state.ProgramChanged)
+		return ok && e.MeIndex == meIndex
+	}
+}
+
+// awaitAck 操作の送信前に呼び出し、反映を待ち始める
+// matchがnilの場合は既に反映済みとしてすぐに完了を表示する
+// 同じContextで待っている操作があれば、新しい操作に置き換える
+func (a *App) awaitAck(ctx context.Context, contextID, host, action string, match ackMatcher) {
+	if match == nil {
+		a.showOk(ctx, contextID)
+		return
+	}
+	p := &pendingAck{
+		host:   host,
+		action: action,
+		match:  match,
+		sentAt: time.Now(),
+	}
+	p.timer = time.AfterFunc(ackTimeout, func() {
+		if a.finishAck(contextID, p) {
+			a.logger.Warn(ctx, "%s %s 操作の反映を確認できませんでした contextID:%s", action, host, contextID)
+			a.showAlert(ctx, contextID)
+		}
+	})
+	if prev, loaded := a.acks.LoadAndStore(contextID, p); loaded {
+		a.finishAck(contextID, prev)
+	}
+}
+
+// failAck 送信に失敗した操作の待機をやめ、ボタンにアラートを表示する
+func (a *App) failAck(ctx context.Context, contextID string) {
+	if p, ok := a.acks.Load(contextID); ok {
+		a.finishAck(contextID, p)
+	}
+	a.showAlert(ctx, contextID)
+}
+
+// finishAck 待機を終了する。既に終了していた場合はfalseを返す
+func (a *App) finishAck(contextID string, p *pendingAck) bool {
+	if !p.done.CompareAndSwap(false, true) {
+		return false
+	}
+	p.timer.Stop()
+	if cur, ok := a.acks.Load(contextID); ok && cur == p {
+		a.acks.Delete(contextID)
+	}
+	return true
+}
+
+// resolveAcks hostの状態変化で、反映を待っている操作を完了または失敗させる
+func (a *App) resolveAcks(ctx context.Context, host string, ev state.Event) {
+	a.acks.Range(func(contextID string, p *pendingAck) bool {
+		if p.host != host {
+			return true
+		}
+		if e, ok := ev.(state.ConnectionChanged); ok {
+			if !e.Connected && a.finishAck(contextID, p) {
+				a.logger.Warn(ctx, "%s %s 操作の反映前に切断されました contextID:%s", p.action, host, contextID)
+				a.showAlert(ctx, contextID)
+			}
+			return true
+		}
+		if p.match(ev) && a.finishAck(contextID, p) {
+			elapsed := time.Since(p.sentAt)
+			a.metrics.confirmation.Observe(elapsed.Seconds(), p.action)
+			a.logger.Debug(ctx, "%s %s 操作の反映を確認 %s", p.action, host, elapsed)
+			a.showOk(ctx, contextID)
+		}
+		return true
+	})
+}
+
+// showOk ボタンに完了を表示する
+func (a *App) showOk(ctx context.Context, contextID string) {
+	if err := a.sd.ShowOk(sdcontext.WithContext(ctx, contextID)); err != nil {
+		a.logger.Error(ctx, "完了の表示に失敗: %v", err)
+		a.sdSendFailed(streamdeck.ShowOk)
+	}
+}
+
+// showAlert ボタンにアラートを表示する
+func (a *App) showAlert(ctx context.Context, contextID string) {
+	if err := a.sd.ShowAlert(sdcontext.WithContext(ctx, contextID)); err != nil {
+		a.logger.Error(ctx, "アラートの表示に失敗: %v", err)
+		a.sdSendFailed(streamdeck.ShowAlert)
+	}
+}
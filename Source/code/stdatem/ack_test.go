@@ -0,0 +1,60 @@
+package stdatem
+
+import (
+	"testing"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+)
+
+func TestExpectProgramPreview(t *testing.T) {
+	st := state.NewSwitcher()
+	st.SetProgram(0, 1)
+	st.SetPreview(0, 2)
+
+	if expectProgram(st, 0, 1) != nil {
+		t.Error("既にPGMにある入力で反映を待ちます")
+	}
+	if expectPreview(st, 0, 2) != nil {
+		t.Error("既にPVWにある入力で反映を待ちます")
+	}
+
+	match := expectProgram(st, 0, 3)
+	for _, ev := range []state.Event{
+		state.PreviewChanged{MeIndex: 0, Input: 3},
+		state.ProgramChanged{MeIndex: 1, Input: 3},
+		state.ProgramChanged{MeIndex: 0, Input: 4},
+	} {
+		if match(ev) {
+			t.Errorf("%#v を反映とみなしました", ev)
+		}
+	}
+	if !match(state.ProgramChanged{MeIndex: 0, Input: 3, Previous: 1}) {
+		t.Error("PGMの変化を反映とみなしません")
+	}
+
+	match = expectPreview(st, 0, 3)
+	if match(state.ProgramChanged{MeIndex: 0, Input: 3}) || !match(state.PreviewChanged{MeIndex: 0, Input: 3}) {
+		t.Error("PVWの変化のみを反映とみなす必要があります")
+	}
+}
+
+func TestExpectCut(t *testing.T) {
+	st := state.NewSwitcher()
+	st.SetProgram(0, 1)
+	st.SetPreview(0, 1)
+	if expectCut(st, 0) != nil {
+		t.Error("PGMとPVWが同じ場合はすぐに完了とする必要があります")
+	}
+
+	st.SetPreview(0, 2)
+	match := expectCut(st, 0)
+	if match == nil {
+		t.Fatal("PGMとPVWが異なる場合に反映を待ちません")
+	}
+	if match(state.PreviewChanged{MeIndex: 0, Input: 1}) || match(state.ProgramChanged{MeIndex: 1, Input: 2}) {
+		t.Error("他のバスやM/Eの変化を反映とみなしました")
+	}
+	if !match(state.ProgramChanged{MeIndex: 0, Input: 2, Previous: 1}) {
+		t.Error("PGMの変化を反映とみなしません")
+	}
+}
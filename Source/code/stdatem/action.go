@@ -0,0 +1,329 @@
+package stdatem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"github.com/FlowingSPDG/streamdeck"
+	sdcontext "github.com/FlowingSPDG/streamdeck/context"
+	"golang.org/x/xerrors"
+)
+
+// actionTarget 解析済みの設定が持つ接続先
+type actionTarget interface {
+	target() (switcher, ip string)
+}
+
+// actionDef アクションの定義
+// SはProperty Inspectorが保存する設定、Pは解析済みの設定
+type actionDef[S any, P actionTarget] struct {
+	uuid     string         // アクションUUID
+	name     string         // ログに表示する名前
+	defaults map[string]any // 設定の既定値
+	parse    func(s *S) (P, error)
+	// keyDown ボタン押下時の処理
+	keyDown func(ctx context.Context, instance *connectionmanager.ATEMInstance, p P) error
+	// render 状態キャッシュからボタンを描画する。nilの場合は描画しない
+	render func(ctx context.Context, contextID string, p P, st state.Switcher)
+	// events 描画に必要な状態変化イベント。nilの場合はイベントを購読しない
+	events func(p P) []state.EventType
+	// expect 送信前の状態から、操作の反映とみなす状態変化を返す
+	// nilの場合は反映を確認できないため、送信に成功しても完了を表示しない
+	expect func(p P, st state.Switcher) ackMatcher
+	// control スイッチャーを操作するアクション
+	// パネルロック中は送信せず、リハーサル中はリハーサル用のスイッチャーに送信する
+	control bool
+	// standalone スイッチャーに紐付けないアクション。keyDownとrenderには接続と状態の代わりにnilを渡す
+	standalone bool
+	// local 押下してもスイッチャーに送信しないアクション
+	// 接続の有無を確認せず、keyDownには接続の代わりにnilを渡す。表示はkeyDownに任せる
+	local bool
+}
+
+// registeredAction 登録済みのアクションを設定の型に依存せずに扱う
+type registeredAction interface {
+	// render Contextをキャッシュ済みの状態で描画する
+	render(ctx context.Context, contextID string, st state.Switcher)
+	didReceiveSettings(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error
+	// lockChanged パネルロックのモードの変化をContextの表示に反映する
+	lockChanged(ctx context.Context, contextID string, mode setting.LockMode)
+}
+
+// action actionDefにStream Deckのイベント処理を結びつけたもの
+type action[S any, P actionTarget] struct {
+	app *App
+	def actionDef[S, P]
+}
+
+// registerAction アクションの全てのStream Deckイベントを登録する
+func registerAction[S any, P actionTarget](a *App, def actionDef[S, P]) {
+	act := &action[S, P]{
+		app: a,
+		def: def,
+	}
+	a.actions[def.uuid] = act
+
+	sdAction := a.sd.Action(def.uuid)
+	sdAction.RegisterHandler(streamdeck.KeyDown, act.keyDown)
+	sdAction.RegisterHandler(streamdeck.KeyUp, act.keyUp)
+	sdAction.RegisterHandler(streamdeck.WillAppear, act.willAppear)
+	sdAction.RegisterHandler(streamdeck.WillDisappear, act.willDisappear)
+	sdAction.RegisterHandler(streamdeck.DidReceiveSettings, act.didReceiveSettings)
+	sdAction.RegisterHandler(streamdeck.TitleParametersDidChange, a.TitleParametersDidChangeHandler)
+	sdAction.RegisterHandler(streamdeck.SendToPlugin, a.SendToPluginHandler)
+}
+
+// load 設定を移行して解析する。不正な設定の場合はボタンにアラートを表示してfalseを返す
+func (act *action[S, P]) load(ctx context.Context, contextID string, raw json.RawMessage, writeBack bool) (P, bool) {
+	var zero P
+	settings, err := decodeSettings[S](ctx, act.app, contextID, raw, act.def.defaults, writeBack)
+	if err != nil {
+		act.app.showInvalidSettings(ctx, contextID, err)
+		return zero, false
+	}
+	parsed, err := act.def.parse(settings)
+	if err != nil {
+		act.app.showInvalidSettings(ctx, contextID, err)
+		return zero, false
+	}
+	return parsed, true
+}
+
+// apply 解析済みの設定をRegistryに登録し、スイッチャーに紐付けて描画する
+func (act *action[S, P]) apply(ctx context.Context, event streamdeck.Event, coordinates streamdeck.Coordinates, raw json.RawMessage, parsed P, debug bool) error {
+	a := act.app
+	entry := setting.Entry{
+		Context:     event.Context,
+		Action:      act.def.uuid,
+		Device:      event.Device,
+		Coordinates: setting.Coordinates{Column: coordinates.Column, Row: coordinates.Row},
+		Settings:    parsed,
+		RawSettings: raw,
+	}
+	if act.def.events != nil {
+		entry.Events = act.def.events(parsed)
+	}
+	if prev, ok := a.registry.Load(event.Context); ok {
+		entry.Title = prev.Title
+	}
+	a.registry.Store(entry)
+
+	if act.def.standalone {
+		a.requestGlobalSettings(ctx)
+		act.render(ctx, event.Context, nil)
+		return nil
+	}
+	if act.def.control {
+		a.renderLockOverlay(ctx, event.Context, a.globalSettings.Load().LockSettings().Mode, false)
+	}
+
+	switcher, ip := parsed.target()
+	if err := a.bindContext(ctx, act.def.uuid, event.Context, switcher, ip, debug); err != nil {
+		return xerrors.Errorf("スイッチャーの紐付けに失敗: %w", err)
+	}
+
+	// キャッシュ済みの状態で描画
+	if instance, ok := a.connectionManager.SolveATEMByContext(ctx, event.Context); ok {
+		act.render(ctx, event.Context, instance.State)
+	}
+	return nil
+}
+
+func (act *action[S, P]) willAppear(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
+	a := act.app
+	var payload streamdeck.WillAppearPayload[json.RawMessage]
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
+		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
+	}
+
+	parsed, ok := act.load(ctx, event.Context, payload.Settings, true)
+	if !ok {
+		return nil
+	}
+	a.logger.Debug(ctx, "%s %#v でWillAppear", act.def.name, parsed)
+
+	return act.apply(ctx, event, payload.Coordinates, payload.Settings, parsed, false)
+}
+
+func (act *action[S, P]) willDisappear(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
+	act.app.cancelArm(event.Context)
+	act.app.handleDisappear(ctx, event.Context)
+	return nil
+}
+
+func (act *action[S, P]) didReceiveSettings(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
+	a := act.app
+	var payload streamdeck.DidReceiveSettingsPayload[json.RawMessage]
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
+		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
+	}
+
+	parsed, ok := act.load(ctx, event.Context, payload.Settings, false)
+	if !ok {
+		return nil
+	}
+	a.logger.Debug(ctx, "%s %#v でDidReceiveSettings", act.def.name, parsed)
+
+	return act.apply(ctx, event, payload.Coordinates, payload.Settings, parsed, true)
+}
+
+func (act *action[S, P]) keyDown(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
+	a := act.app
+	var payload streamdeck.KeyDownPayload[json.RawMessage]
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
+		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
+	}
+
+	parsed, ok := act.load(ctx, event.Context, payload.Settings, false)
+	if !ok {
+		return nil
+	}
+	a.logger.Debug(ctx, "%s %v でKeyDown", act.def.name, parsed)
+	a.metrics.keyPresses.Inc(act.def.name)
+
+	// マルチアクションの中では確定の操作ができないため、誤操作防止モードを適用しない
+	if arm, ok := any(parsed).(armable); ok && arm.arm().Mode != armModeOff && !payload.IsInMultiAction {
+		a.armKeyDown(ctx, event.Context, act.def.name, arm.arm(), func() {
+			if err := act.fire(ctx, event.Context, parsed); err != nil {
+				a.logger.Error(ctx, "%v", err)
+			}
+		}, func() {
+			act.restore(ctx, event.Context)
+		})
+		return nil
+	}
+	return act.fire(ctx, event.Context, parsed)
+}
+
+func (act *action[S, P]) keyUp(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
+	a := act.app
+	entry, ok := a.registry.Load(event.Context)
+	if !ok {
+		return nil
+	}
+	if arm, ok := entry.Settings.(armable); ok {
+		a.armKeyUp(ctx, event.Context, act.def.name, arm.arm(), func() {
+			act.restore(ctx, event.Context)
+		})
+	}
+	return nil
+}
+
+// fire ボタンの操作をスイッチャーに送信する
+func (act *action[S, P]) fire(ctx context.Context, contextID string, parsed P) error {
+	a := act.app
+	if act.def.standalone || act.def.local {
+		return act.def.keyDown(ctx, nil, parsed)
+	}
+	instance, ok := a.connectionManager.SolveATEMByContext(ctx, contextID)
+	if !ok {
+		a.logger.Error(ctx, "%s KeyDown ATEMが見つかりません", act.def.name)
+		a.showAlert(ctx, contextID)
+		return xerrors.Errorf("%s KeyDown ATEMが見つかりません", act.def.name)
+	}
+	if act.def.control {
+		routed, err := a.routeCommand(ctx, instance)
+		if err != nil {
+			a.logger.Info(ctx, "%s KeyDown 送信しません: %v", act.def.name, err)
+			a.showAlert(ctx, contextID)
+			return nil
+		}
+		instance = routed
+	}
+
+	if !instance.State.Connected() {
+		a.logger.Warn(ctx, "%s KeyDown ATEM %s に接続されていません", act.def.name, instance.Client.IP())
+		a.showAlert(ctx, contextID)
+		return nil
+	}
+	if act.def.expect != nil {
+		// 送信直後に届く状態変化を取りこぼさないよう、送信前に待ち始める
+		a.awaitAck(ctx, contextID, instance.Client.IP(), act.def.name, act.def.expect(parsed, instance.State))
+	}
+
+	if err := act.def.keyDown(ctx, instance, parsed); err != nil {
+		a.tracker.Error(instance.Client.IP(), err)
+		a.logger.Error(ctx, "%s KeyDown 送信に失敗: %v", act.def.name, err)
+		if act.def.expect != nil {
+			a.failAck(ctx, contextID)
+		} else {
+			a.showAlert(ctx, contextID)
+		}
+		return xerrors.Errorf("%s KeyDown 送信に失敗: %w", act.def.name, err)
+	}
+	a.logger.Debug(ctx, "%s KeyDown 送信", act.def.name)
+	return nil
+}
+
+// restore 確定待ちの表示を元に戻す。描画しないアクションはマニフェストの画像に戻す
+func (act *action[S, P]) restore(ctx context.Context, contextID string) {
+	a := act.app
+	if act.def.standalone {
+		act.render(ctx, contextID, nil)
+		return
+	}
+	if act.def.render != nil {
+		if instance, ok := a.connectionManager.SolveATEMByContext(ctx, contextID); ok {
+			act.render(ctx, contextID, instance.State)
+		}
+		return
+	}
+	if err := a.sd.SetImage(sdcontext.WithContext(ctx, contextID), "", streamdeck.HardwareAndSoftware); err != nil {
+		a.logger.Error(ctx, "画像のリセットに失敗: %v", err)
+		a.sdSendFailed(streamdeck.SetImage)
+	}
+}
+
+func (act *action[S, P]) render(ctx context.Context, contextID string, st state.Switcher) {
+	// 確定待ちの表示は解除されるまで上書きしない
+	if act.def.render == nil || act.app.armed(contextID) {
+		return
+	}
+	entry, ok := act.app.registry.Load(contextID)
+	if !ok {
+		act.app.logger.Error(ctx, "%s 設定が見つかりません contextID:%s", act.def.name, contextID)
+		return
+	}
+	parsed, ok := entry.Settings.(P)
+	if !ok {
+		act.app.logger.Error(ctx, "%s 設定の型が一致しません contextID:%s", act.def.name, contextID)
+		return
+	}
+	act.def.render(ctx, contextID, parsed, st)
+}
+
+func (act *action[S, P]) lockChanged(ctx context.Context, contextID string, mode setting.LockMode) {
+	switch {
+	case act.def.standalone:
+		act.render(ctx, contextID, nil)
+	case act.def.control:
+		act.app.renderLockOverlay(ctx, contextID, mode, true)
+	}
+}
+
+// SendToPluginHandler Property Inspectorからの操作をcommandごとに振り分ける
+func (a *App) SendToPluginHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
+	var cmd struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal(event.Payload, &cmd); err != nil {
+		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
+		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
+	}
+	switch cmd.Command {
+	case profileCommandExport, profileCommandImport:
+		return a.ProfileSendToPluginHandler(ctx, client, event)
+	case cutListCommandExport, cutListCommandClear:
+		return a.CutListSendToPluginHandler(ctx, client, event)
+	case diagnosticsCommandGet, diagnosticsCommandExport:
+		return a.DiagnosticsSendToPluginHandler(ctx, client, event)
+	}
+	return nil
+}
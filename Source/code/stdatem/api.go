@@ -0,0 +1,104 @@
+package stdatem
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/api"
+	"github.com/FlowingSPDG/std-atem/Source/code/asrun"
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+	"golang.org/x/xerrors"
+)
+
+// apiServiceName ローカルHTTP APIのサービス名
+const apiServiceName = "api"
+
+// apiService 起動中のローカルHTTP API
+type apiService struct {
+	settings   setting.APISettings
+	server     api.Server
+	controller *switcherController
+}
+
+// applyAPI グローバル設定に従ってローカルHTTP APIを起動・停止する
+func (a *App) applyAPI(ctx context.Context) {
+	settings := a.globalSettings.Load().APISettings()
+
+	if a.api != nil && !sameAPIServer(a.api.settings, settings) {
+		a.stopAPI(ctx)
+	}
+	if !settings.Enabled || a.api != nil {
+		return
+	}
+
+	// 認証なしでは起動せず、トークンが未設定の場合は生成してグローバル設定に保存する
+	if settings.Token == "" {
+		token, err := generateAPIToken()
+		if err != nil {
+			a.logger.Error(ctx, "HTTP APIのトークンの生成に失敗: %v", err)
+			return
+		}
+		if err := a.updateGlobalSettings(ctx, func(gs *setting.GlobalSettings) {
+			api := gs.APISettings()
+			api.Token = token
+			gs.API = &api
+		}); err != nil {
+			a.logger.Error(ctx, "HTTP APIのトークンの保存に失敗: %v", err)
+			return
+		}
+		settings.Token = token
+		a.logger.Info(ctx, "HTTP APIのトークンを生成しました。プロパティインスペクターで確認できます")
+	}
+
+	controller := a.newSwitcherController(apiServiceName, asrun.OriginAPI)
+	server, err := api.NewServer(api.Config{
+		Address:        settings.Address,
+		Token:          settings.Token,
+		AllowedOrigins: settings.AllowedOrigins,
+		Controller:     controller,
+		Logger:         a.logger.With("service", "api"),
+	})
+	if err != nil {
+		a.logger.Error(ctx, "HTTP APIの起動に失敗: %v", err)
+		return
+	}
+	a.logger.Info(ctx, "HTTP APIを開始 %s", server.Addr())
+	go func() {
+		if err := server.Run(ctx); err != nil {
+			a.logger.Error(ctx, "HTTP APIが終了しました: %v", err)
+		}
+	}()
+	a.api = &apiService{settings: settings, server: server, controller: controller}
+}
+
+// stopAPI ローカルHTTP APIを停止し、APIが保持していたスイッチャーとの紐付けを解除する
+func (a *App) stopAPI(ctx context.Context) {
+	if a.api == nil {
+		return
+	}
+	if err := a.api.server.Close(); err != nil {
+		a.logger.Warn(ctx, "HTTP APIの停止に失敗: %v", err)
+	}
+	a.api.controller.release(ctx)
+	a.api = nil
+	a.logger.Info(ctx, "HTTP APIを停止")
+}
+
+// sameAPIServer 設定が同じHTTP APIのサーバーになるか
+func sameAPIServer(x, y setting.APISettings) bool {
+	return x.Enabled == y.Enabled &&
+		x.Address == y.Address &&
+		x.Token == y.Token &&
+		slices.Equal(x.AllowedOrigins, y.AllowedOrigins)
+}
+
+// generateAPIToken HTTP APIのトークンを生成する
+func generateAPIToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", xerrors.Errorf("乱数の生成に失敗: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
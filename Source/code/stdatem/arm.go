@@ -0,0 +1,146 @@
+package stdatem
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/FlowingSPDG/streamdeck"
+	sdcontext "github.com/FlowingSPDG/streamdeck/context"
+	"golang.org/x/xerrors"
+)
+
+// armMode 誤操作防止のための押下の確定方法
+type armMode string
+
+const (
+	// armModeOff 押下ですぐに実行する
+	armModeOff armMode = ""
+	// armModeHold 一定時間押し続けると実行する
+	armModeHold armMode = "hold"
+	// armModeDouble 一定時間内にもう一度押すと実行する
+	armModeDouble armMode = "double"
+)
+
+const (
+	// defaultArmHold 長押しで実行するまでの既定の時間
+	defaultArmHold = time.Second
+	// defaultArmWindow 2回目の押下を待つ既定の時間
+	defaultArmWindow = 1500 * time.Millisecond
+)
+
+// armConfig 誤操作防止モードの設定
+type armConfig struct {
+	Mode     armMode
+	Duration time.Duration // 長押しの時間、または2回目の押下を待つ時間
+}
+
+// armable 誤操作防止モードに対応した解析済みの設定
+type armable interface {
+	arm() armConfig
+}
+
+// parseArm armMode/armTime(ミリ秒)を解析する。未設定の場合は誤操作防止モードを無効とする
+func parseArm(mode string, armTime json.Number) (armConfig, error) {
+	cfg := armConfig{Mode: armMode(mode)}
+	switch cfg.Mode {
+	case armModeHold:
+		cfg.Duration = defaultArmHold
+	case armModeDouble:
+		cfg.Duration = defaultArmWindow
+	default:
+		return armConfig{Mode: armModeOff}, nil
+	}
+	if armTime == "" {
+		return cfg, nil
+	}
+	ms, err := armTime.Int64()
+	if err != nil {
+		return armConfig{}, xerrors.Errorf("armTimeの解析に失敗: %w", err)
+	}
+	if ms > 0 {
+		cfg.Duration = time.Duration(ms) * time.Millisecond
+	}
+	return cfg, nil
+}
+
+// armState 確定を待っているボタン
+type armState struct {
+	timer *time.Timer
+	done  atomic.Bool
+}
+
+// armKeyDown 誤操作防止モードのボタン押下を処理する。実行を確定した場合はfireを呼び出す
+func (a *App) armKeyDown(ctx context.Context, contextID, name string, cfg armConfig, fire func(), restore func()) {
+	if cfg.Mode == armModeDouble {
+		if s, ok := a.arms.Load(contextID); ok && a.disarm(contextID, s) {
+			a.logger.Debug(ctx, "%s 2回目の押下で実行 contextID:%s", name, contextID)
+			restore()
+			fire()
+			return
+		}
+	}
+
+	s := &armState{}
+	s.timer = time.AfterFunc(cfg.Duration, func() {
+		if !a.disarm(contextID, s) {
+			return
+		}
+		restore()
+		if cfg.Mode == armModeHold {
+			a.logger.Debug(ctx, "%s 長押しで実行 contextID:%s", name, contextID)
+			fire()
+			return
+		}
+		a.logger.Debug(ctx, "%s 2回目の押下がないため解除 contextID:%s", name, contextID)
+	})
+	if prev, loaded := a.arms.LoadAndStore(contextID, s); loaded {
+		a.disarm(contextID, prev)
+	}
+	a.showArmed(ctx, contextID)
+}
+
+// armKeyUp 長押しの途中で離された場合は実行せずに解除する
+func (a *App) armKeyUp(ctx context.Context, contextID, name string, cfg armConfig, restore func()) {
+	if cfg.Mode != armModeHold {
+		return
+	}
+	if s, ok := a.arms.Load(contextID); ok && a.disarm(contextID, s) {
+		a.logger.Debug(ctx, "%s 長押しの途中で離されたため解除 contextID:%s", name, contextID)
+		restore()
+	}
+}
+
+// cancelArm 確定を待っているボタンがあれば実行せずに解除する
+func (a *App) cancelArm(contextID string) {
+	if s, ok := a.arms.Load(contextID); ok {
+		a.disarm(contextID, s)
+	}
+}
+
+// armed 確定を待っている場合はtrueを返す
+func (a *App) armed(contextID string) bool {
+	s, ok := a.arms.Load(contextID)
+	return ok && !s.done.Load()
+}
+
+// disarm 確定待ちを終了する。既に終了していた場合はfalseを返す
+func (a *App) disarm(contextID string, s *armState) bool {
+	if !s.done.CompareAndSwap(false, true) {
+		return false
+	}
+	s.timer.Stop()
+	if cur, ok := a.arms.Load(contextID); ok && cur == s {
+		a.arms.Delete(contextID)
+	}
+	return true
+}
+
+// showArmed ボタンを確定待ちの表示にする
+func (a *App) showArmed(ctx context.Context, contextID string) {
+	if err := a.sd.SetImage(sdcontext.WithContext(ctx, contextID), armedImage, streamdeck.HardwareAndSoftware); err != nil {
+		a.logger.Error(ctx, "確定待ちの画像の設定に失敗: %v", err)
+		a.sdSendFailed(streamdeck.SetImage)
+	}
+}
@@ -0,0 +1,59 @@
+package stdatem
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseArm(t *testing.T) {
+	tests := []struct {
+		mode    string
+		armTime json.Number
+		want    armConfig
+	}{
+		{"", "", armConfig{Mode: armModeOff}},
+		{"", "500", armConfig{Mode: armModeOff}},
+		{"unknown", "500", armConfig{Mode: armModeOff}},
+		{"hold", "", armConfig{Mode: armModeHold, Duration: defaultArmHold}},
+		{"double", "", armConfig{Mode: armModeDouble, Duration: defaultArmWindow}},
+		{"hold", "250", armConfig{Mode: armModeHold, Duration: 250 * time.Millisecond}},
+		{"double", "0", armConfig{Mode: armModeDouble, Duration: defaultArmWindow}},
+	}
+	for _, tt := range tests {
+		got, err := parseArm(tt.mode, tt.armTime)
+		if err != nil {
+			t.Errorf("parseArm(%q, %q): %v", tt.mode, tt.armTime, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseArm(%q, %q) = %+v, want %+v", tt.mode, tt.armTime, got, tt.want)
+		}
+	}
+
+	if _, err := parseArm("hold", "1.5s"); err == nil {
+		t.Error("不正なarmTimeでエラーになりません")
+	}
+}
+
+func TestArmableActions(t *testing.T) {
+	// 誤操作防止モードはスイッチャーを切り替えるアクションのみが対応する
+	for _, p := range []any{
+		&programPropertyInspector{},
+		&cutPropertyInspector{},
+		&autoPropertyInspector{},
+		&ftbPropertyInspector{},
+	} {
+		if _, ok := p.(armable); !ok {
+			t.Errorf("%T が誤操作防止モードに対応していません", p)
+		}
+	}
+	for _, p := range []any{
+		&previewPropertyInspector{},
+		&lockPropertyInspector{},
+	} {
+		if _, ok := p.(armable); ok {
+			t.Errorf("%T が誤操作防止モードに対応しています", p)
+		}
+	}
+}
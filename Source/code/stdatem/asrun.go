@@ -0,0 +1,83 @@
+package stdatem
+
+import (
+	"context"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/asrun"
+	"github.com/FlowingSPDG/std-atem/Source/code/atemclient"
+	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+)
+
+// asrunEvents As-Runログに記録する状態変化
+var asrunEvents = []state.EventType{
+	state.EventProgram,
+}
+
+// asrunService 記録中のAs-Runログ
+type asrunService struct {
+	settings setting.AsRunSettings
+	recorder asrun.Recorder
+}
+
+// applyAsRun グローバル設定に従ってAs-Runログの記録を開始・停止する
+func (a *App) applyAsRun(ctx context.Context) {
+	settings := a.globalSettings.Load().AsRunSettings()
+
+	if current := a.asrun.Load(); current != nil && current.settings != settings {
+		a.stopAsRun(ctx)
+	}
+	if !settings.Enabled || a.asrun.Load() != nil {
+		return
+	}
+
+	recorder, err := asrun.NewRecorder(asrun.Config{
+		Dir:     a.pluginPath(settings.Dir, asrun.DefaultDir),
+		MaxSize: int64(settings.MaxSizeMB) * 1024 * 1024,
+		MaxAge:  time.Duration(settings.MaxAgeDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		a.logger.Error(ctx, "As-Runログの開始に失敗: %v", err)
+		return
+	}
+	a.logger.Info(ctx, "As-Runログを開始 %s", recorder.Path())
+	a.asrun.Store(&asrunService{settings: settings, recorder: recorder})
+}
+
+// stopAsRun As-Runログの記録を停止する
+func (a *App) stopAsRun(ctx context.Context) {
+	current := a.asrun.Swap(nil)
+	if current == nil {
+		return
+	}
+	if err := current.recorder.Close(); err != nil {
+		a.logger.Warn(ctx, "As-Runログのクローズに失敗: %v", err)
+	}
+	a.logger.Info(ctx, "As-Runログを停止")
+}
+
+// programCommand PGMを切り替える操作を送信し、送信できた場合はAs-Runログの発生元の判定に記録する
+// PVWの変更など、PGMを変えない操作を記録すると直後の本体での切り替えを誤って自分の操作とみなすため、PGMを切り替える操作のみに使う
+func (a *App) programCommand(instance *connectionmanager.ATEMInstance, origin asrun.Origin, send func() error) error {
+	if err := send(); err != nil {
+		return err
+	}
+	if current := a.asrun.Load(); current != nil {
+		current.recorder.Command(instance.Client.IP(), origin)
+	}
+	return nil
+}
+
+// recordAsRun 状態変化をAs-Runログに記録する
+func (a *App) recordAsRun(ctx context.Context, instance *connectionmanager.ATEMInstance, ev state.Event) {
+	current := a.asrun.Load()
+	if current == nil {
+		return
+	}
+	timecode, _, _ := instance.Client.Timecode()
+	if err := current.recorder.Record(instance.Client.IP(), ev, timecode, atemclient.TimecodeSourceHostClock); err != nil {
+		a.logger.Error(ctx, "As-Runログの記録に失敗: %v", err)
+	}
+}
@@ -4,13 +4,17 @@ import (
 	"encoding/json"
 
 	"github.com/FlowingSPDG/go-atem"
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
 	"golang.org/x/xerrors"
 )
 
 type PreviewPropertyInspector struct {
-	IP      string      `json:"ip"`
-	Input   json.Number `json:"input"`
-	MeIndex json.Number `json:"meIndex"`
+	Version   json.Number `json:"version"`
+	Switcher  string      `json:"switcher"`
+	IP        string      `json:"ip"`
+	Input     json.Number `json:"input"`
+	MeIndex   json.Number `json:"meIndex"`
+	TallyMode json.Number `json:"tallyMode"`
 }
 
 func (p *PreviewPropertyInspector) Parse() (*previewPropertyInspector, error) {
@@ -24,29 +28,46 @@ func (p *PreviewPropertyInspector) Parse() (*previewPropertyInspector, error) {
 		return nil, xerrors.Errorf("meIndexの解析に失敗: %w", err)
 	}
 
+	tallyMode, err := parseTallyMode(p.TallyMode)
+	if err != nil {
+		return nil, err
+	}
+
 	return &previewPropertyInspector{
-		IP:      ip,
-		Input:   solveATEMVideoInput(input),
-		MeIndex: uint8(meIndex),
+		Switcher:  p.Switcher,
+		IP:        ip,
+		Input:     solveATEMVideoInput(input),
+		MeIndex:   uint8(meIndex),
+		TallyMode: tallyMode,
 	}, nil
 }
 
 type previewPropertyInspector struct {
-	IP      string
-	Input   atem.VideoInputType
-	MeIndex uint8
+	Switcher  string
+	IP        string
+	Input     atem.VideoInputType
+	MeIndex   uint8
+	TallyMode setting.TallyMode
 }
 
 type ProgramPropertyInspector struct {
-	IP      string      `json:"ip"`
-	Input   json.Number `json:"input"`
-	MeIndex json.Number `json:"meIndex"`
+	Version   json.Number `json:"version"`
+	Switcher  string      `json:"switcher"`
+	IP        string      `json:"ip"`
+	Input     json.Number `json:"input"`
+	MeIndex   json.Number `json:"meIndex"`
+	TallyMode json.Number `json:"tallyMode"`
+	ArmMode   string      `json:"armMode"`
+	ArmTime   json.Number `json:"armTime"`
 }
 
 type programPropertyInspector struct {
-	IP      string
-	Input   atem.VideoInputType
-	MeIndex uint8
+	Switcher  string
+	IP        string
+	Input     atem.VideoInputType
+	MeIndex   uint8
+	TallyMode setting.TallyMode
+	Arm       armConfig
 }
 
 func (p *ProgramPropertyInspector) Parse() (*programPropertyInspector, error) {
@@ -60,13 +81,217 @@ func (p *ProgramPropertyInspector) Parse() (*programPropertyInspector, error) {
 		return nil, xerrors.Errorf("meIndexの解析に失敗: %w", err)
 	}
 
+	tallyMode, err := parseTallyMode(p.TallyMode)
+	if err != nil {
+		return nil, err
+	}
+	arm, err := parseArm(p.ArmMode, p.ArmTime)
+	if err != nil {
+		return nil, err
+	}
+
 	return &programPropertyInspector{
-		IP:      ip,
-		Input:   solveATEMVideoInput(input),
-		MeIndex: uint8(meIndex),
+		Switcher:  p.Switcher,
+		IP:        ip,
+		Input:     solveATEMVideoInput(input),
+		MeIndex:   uint8(meIndex),
+		TallyMode: tallyMode,
+		Arm:       arm,
+	}, nil
+}
+
+// inputPressMode Inputボタン押下時の動作
+type inputPressMode string
+
+const (
+	// inputPressPreview PVWに設定する
+	inputPressPreview inputPressMode = "preview"
+	// inputPressProgram PGMに設定する
+	inputPressProgram inputPressMode = "program"
+	// inputPressPreviewThenCut 1回目の押下でPVWに設定し、PVWに設定済みの場合はCutする
+	inputPressPreviewThenCut inputPressMode = "previewThenCut"
+)
+
+type InputPropertyInspector struct {
+	Version   json.Number `json:"version"`
+	Switcher  string      `json:"switcher"`
+	IP        string      `json:"ip"`
+	Input     json.Number `json:"input"`
+	MeIndex   json.Number `json:"meIndex"`
+	PressMode string      `json:"pressMode"`
+}
+
+type inputPropertyInspector struct {
+	Switcher  string
+	IP        string
+	Input     atem.VideoInputType
+	MeIndex   uint8
+	PressMode inputPressMode
+}
+
+func (p *InputPropertyInspector) Parse() (*inputPropertyInspector, error) {
+	input, err := p.Input.Int64()
+	if err != nil {
+		return nil, xerrors.Errorf("inputの解析に失敗: %w", err)
+	}
+	meIndex, err := p.MeIndex.Int64()
+	if err != nil {
+		return nil, xerrors.Errorf("meIndexの解析に失敗: %w", err)
+	}
+
+	pressMode := inputPressMode(p.PressMode)
+	switch pressMode {
+	case inputPressPreview, inputPressProgram, inputPressPreviewThenCut:
+	default:
+		pressMode = inputPressPreview
+	}
+
+	return &inputPropertyInspector{
+		Switcher:  p.Switcher,
+		IP:        p.IP,
+		Input:     solveATEMVideoInput(input),
+		MeIndex:   uint8(meIndex),
+		PressMode: pressMode,
 	}, nil
 }
 
+// parseTallyMode tallyModeを解析する。未設定の場合はTallyModeTALLYとして扱う
+func parseTallyMode(n json.Number) (setting.TallyMode, error) {
+	if n == "" {
+		return setting.TallyModeTALLY, nil
+	}
+	v, err := n.Int64()
+	if err != nil {
+		return 0, xerrors.Errorf("tallyModeの解析に失敗: %w", err)
+	}
+	return setting.ParseTallyMode(v), nil
+}
+
 type AutoPropertyInspector struct {
-	IP string `json:"ip"`
+	Version  json.Number `json:"version"`
+	Switcher string      `json:"switcher"`
+	IP       string      `json:"ip"`
+	ArmMode  string      `json:"armMode"`
+	ArmTime  json.Number `json:"armTime"`
+}
+
+type autoPropertyInspector struct {
+	Switcher string
+	IP       string
+	Arm      armConfig
+}
+
+func (p *AutoPropertyInspector) Parse() (*autoPropertyInspector, error) {
+	arm, err := parseArm(p.ArmMode, p.ArmTime)
+	if err != nil {
+		return nil, err
+	}
+	return &autoPropertyInspector{
+		Switcher: p.Switcher,
+		IP:       p.IP,
+		Arm:      arm,
+	}, nil
+}
+
+type CutPropertyInspector struct {
+	Version  json.Number `json:"version"`
+	Switcher string      `json:"switcher"`
+	IP       string      `json:"ip"`
+	ArmMode  string      `json:"armMode"`
+	ArmTime  json.Number `json:"armTime"`
+}
+
+type cutPropertyInspector struct {
+	Switcher string
+	IP       string
+	Arm      armConfig
+}
+
+func (p *CutPropertyInspector) Parse() (*cutPropertyInspector, error) {
+	arm, err := parseArm(p.ArmMode, p.ArmTime)
+	if err != nil {
+		return nil, err
+	}
+	return &cutPropertyInspector{
+		Switcher: p.Switcher,
+		IP:       p.IP,
+		Arm:      arm,
+	}, nil
+}
+
+type FTBPropertyInspector struct {
+	Version  json.Number `json:"version"`
+	Switcher string      `json:"switcher"`
+	IP       string      `json:"ip"`
+	ArmMode  string      `json:"armMode"`
+	ArmTime  json.Number `json:"armTime"`
+}
+
+type ftbPropertyInspector struct {
+	Switcher string
+	IP       string
+	Arm      armConfig
+}
+
+func (p *FTBPropertyInspector) Parse() (*ftbPropertyInspector, error) {
+	arm, err := parseArm(p.ArmMode, p.ArmTime)
+	if err != nil {
+		return nil, err
+	}
+	return &ftbPropertyInspector{
+		Switcher: p.Switcher,
+		IP:       p.IP,
+		Arm:      arm,
+	}, nil
+}
+
+type DiagnosticsPropertyInspector struct {
+	Version  json.Number `json:"version"`
+	Switcher string      `json:"switcher"`
+	IP       string      `json:"ip"`
+}
+
+type diagnosticsPropertyInspector struct {
+	Switcher string
+	IP       string
 }
+
+func (p *DiagnosticsPropertyInspector) Parse() (*diagnosticsPropertyInspector, error) {
+	return &diagnosticsPropertyInspector{
+		Switcher: p.Switcher,
+		IP:       p.IP,
+	}, nil
+}
+
+type LockPropertyInspector struct {
+	Version  json.Number `json:"version"`
+	LockMode string      `json:"lockMode"`
+}
+
+type lockPropertyInspector struct {
+	Mode setting.LockMode // ボタンの押下で切り替えるモード
+}
+
+func (p *LockPropertyInspector) Parse() (*lockPropertyInspector, error) {
+	mode := setting.LockMode(p.LockMode)
+	if mode != setting.LockModeRehearsal {
+		mode = setting.LockModeLocked
+	}
+	return &lockPropertyInspector{
+		Mode: mode,
+	}, nil
+}
+
+func (p *previewPropertyInspector) target() (string, string)     { return p.Switcher, p.IP }
+func (p *programPropertyInspector) target() (string, string)     { return p.Switcher, p.IP }
+func (p *inputPropertyInspector) target() (string, string)       { return p.Switcher, p.IP }
+func (p *autoPropertyInspector) target() (string, string)        { return p.Switcher, p.IP }
+func (p *cutPropertyInspector) target() (string, string)         { return p.Switcher, p.IP }
+func (p *ftbPropertyInspector) target() (string, string)         { return p.Switcher, p.IP }
+func (p *diagnosticsPropertyInspector) target() (string, string) { return p.Switcher, p.IP }
+func (p *lockPropertyInspector) target() (string, string)        { return "", "" }
+
+func (p *programPropertyInspector) arm() armConfig { return p.Arm }
+func (p *autoPropertyInspector) arm() armConfig    { return p.Arm }
+func (p *cutPropertyInspector) arm() armConfig     { return p.Arm }
+func (p *ftbPropertyInspector) arm() armConfig     { return p.Arm }
@@ -0,0 +1,157 @@
+package stdatem
+
+import (
+	"context"
+
+	"github.com/FlowingSPDG/go-atem"
+	"github.com/FlowingSPDG/std-atem/Source/code/api"
+	"github.com/FlowingSPDG/std-atem/Source/code/asrun"
+	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+	"github.com/FlowingSPDG/std-atem/Source/code/osc"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"github.com/puzpuzpuz/xsync/v3"
+	"golang.org/x/xerrors"
+)
+
+var (
+	_ api.Controller = (*switcherController)(nil)
+	_ osc.Controller = (*switcherController)(nil)
+)
+
+// switcherController 外部インターフェースからのスイッチャー操作をAppの共有接続で実装する
+// api.Controllerとosc.Controllerを満たす
+type switcherController struct {
+	app       *App
+	service   string                         // 接続を保持するサービス名
+	origin    asrun.Origin                   // As-Runログに記録する発生元
+	switchers *xsync.MapOf[string, struct{}] // 利用したスイッチャー名
+}
+
+// newSwitcherController サービスserviceのswitcherControllerを初期化する
+func (a *App) newSwitcherController(service string, origin asrun.Origin) *switcherController {
+	return &switcherController{
+		app:       a,
+		service:   service,
+		origin:    origin,
+		switchers: xsync.NewMapOf[string, struct{}](),
+	}
+}
+
+// release 利用した全てのスイッチャーとの紐付けを解除する
+func (c *switcherController) release(ctx context.Context) {
+	c.switchers.Range(func(name string, _ struct{}) bool {
+		c.app.releaseSwitcher(ctx, c.service+"/"+name)
+		return true
+	})
+}
+
+func (c *switcherController) Switchers() []string {
+	gs := c.app.globalSettings.Load()
+	if gs == nil {
+		return []string{}
+	}
+	names := make([]string, 0, len(gs.Switchers))
+	for _, s := range gs.Switchers {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// instance 名前に対応する共有のATEMInstanceを取得する。接続していない場合は接続を開始する
+// 外部から任意のホストに接続させないよう、グローバル設定の名前付きスイッチャーのみを受け付ける
+func (c *switcherController) instance(ctx context.Context, name string) (*connectionmanager.ATEMInstance, error) {
+	if _, ok := c.app.globalSettings.Load().Switcher(name); !ok {
+		return nil, xerrors.Errorf("%s: %w", name, api.ErrSwitcherNotFound)
+	}
+	instance, ok := c.app.acquireSwitcher(ctx, c.service+"/"+name, name)
+	if !ok {
+		return nil, xerrors.Errorf("%s: %w", name, api.ErrSwitcherNotFound)
+	}
+	c.switchers.Store(name, struct{}{})
+	return instance, nil
+}
+
+// connected 操作を送信する接続済みのATEMInstanceを取得する
+func (c *switcherController) connected(ctx context.Context, name string) (*connectionmanager.ATEMInstance, error) {
+	instance, err := c.instance(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if instance, err = c.app.routeCommand(ctx, instance); err != nil {
+		return nil, xerrors.Errorf("%s: %w", name, err)
+	}
+	if !instance.State.Connected() {
+		return nil, xerrors.Errorf("%s: %w", name, api.ErrNotConnected)
+	}
+	return instance, nil
+}
+
+func (c *switcherController) State(ctx context.Context, name string) (state.Switcher, error) {
+	instance, err := c.instance(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return instance.State, nil
+}
+
+func (c *switcherController) Cut(ctx context.Context, name string, meIndex uint8) error {
+	instance, err := c.connected(ctx, name)
+	if err != nil {
+		return err
+	}
+	return c.app.programCommand(instance, c.origin, func() error {
+		return instance.Client.PerformCut(meIndex)
+	})
+}
+
+func (c *switcherController) Auto(ctx context.Context, name string, meIndex uint8) error {
+	instance, err := c.connected(ctx, name)
+	if err != nil {
+		return err
+	}
+	return c.app.programCommand(instance, c.origin, func() error {
+		return instance.Client.PerformAuto(meIndex)
+	})
+}
+
+// videoInput 入力番号がスイッチャーのビデオソースとして定義されているか確認する
+func videoInput(input uint16) (atem.VideoInputType, error) {
+	if _, ok := atem.VideoSourceType[input]; !ok {
+		return 0, xerrors.Errorf("%d: %w", input, api.ErrUnknownInput)
+	}
+	return atem.VideoInputType(input), nil
+}
+
+func (c *switcherController) SetProgram(ctx context.Context, name string, meIndex uint8, input uint16) error {
+	videoInput, err := videoInput(input)
+	if err != nil {
+		return err
+	}
+	instance, err := c.connected(ctx, name)
+	if err != nil {
+		return err
+	}
+	return c.app.programCommand(instance, c.origin, func() error {
+		return instance.Client.SetProgramInput(videoInput, meIndex)
+	})
+}
+
+func (c *switcherController) SetPreview(ctx context.Context, name string, meIndex uint8, input uint16) error {
+	videoInput, err := videoInput(input)
+	if err != nil {
+		return err
+	}
+	instance, err := c.connected(ctx, name)
+	if err != nil {
+		return err
+	}
+	return instance.Client.SetPreviewInput(videoInput, meIndex)
+}
+
+func (c *switcherController) SetDSKOnAir(ctx context.Context, name string, index uint8, onAir bool) error {
+	instance, err := c.connected(ctx, name)
+	if err != nil {
+		return err
+	}
+	return instance.Client.SetDSKOnAir(index, onAir)
+}
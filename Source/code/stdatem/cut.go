@@ -2,79 +2,30 @@ package stdatem
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 
-	"github.com/FlowingSPDG/streamdeck"
-	"golang.org/x/xerrors"
+	"github.com/FlowingSPDG/std-atem/Source/code/asrun"
+	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
 )
 
-// CutWillAppearHandler ATEM Cutを設定
-func (a *App) CutWillAppearHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
-	var payload streamdeck.WillAppearPayload[*AutoPropertyInspector]
-	if err := json.Unmarshal(event.Payload, &payload); err != nil {
-		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
-		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
+// cutActionDef ATEM Cutを実行するアクション
+func (a *App) cutActionDef() actionDef[CutPropertyInspector, *cutPropertyInspector] {
+	return actionDef[CutPropertyInspector, *cutPropertyInspector]{
+		uuid:     cutAction,
+		name:     "Cut",
+		defaults: transitionSettingsDefaults,
+		parse:    (*CutPropertyInspector).Parse,
+		keyDown:  a.cutKeyDown,
+		control:  true,
+		expect: func(_ *cutPropertyInspector, st state.Switcher) ackMatcher {
+			return expectCut(st, 0)
+		},
 	}
-
-	msg := fmt.Sprintf("Cut %#v でWillAppear", payload.Settings)
-	a.logger.Debug(ctx, msg)
-
-	// 新しいインスタンスを初期化
-	if err := a.addATEMHost(ctx, cutAction, event.Context, payload.Settings.IP, false); err != nil {
-		return xerrors.Errorf("ATEMホストの追加に失敗: %w", err)
-	}
-
-	return nil
-}
-
-// CutWillDisappearHandler Cutのボタン非表示を処理
-func (a *App) CutWillDisappearHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
-	var payload streamdeck.WillDisappearPayload[AutoPropertyInspector]
-	if err := json.Unmarshal(event.Payload, &payload); err != nil {
-		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
-		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
-	}
-	a.handleDisappear(ctx, event.Context)
-	return nil
 }
 
-// CutKeyDownHandler ATEM Cutを実行
-func (a *App) CutKeyDownHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
-	var payload streamdeck.KeyDownPayload[AutoPropertyInspector]
-	if err := json.Unmarshal(event.Payload, &payload); err != nil {
-		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
-		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
-	}
-
-	msg := fmt.Sprintf("Cut %v でKeyDown", payload.Settings)
-	a.logger.Debug(ctx, msg)
-
-	instance, ok := a.connectionManager.SolveATEMByContext(ctx, event.Context)
-	if !ok {
-		a.logger.Error(ctx, "CutKeyDownHandler ATEMが見つかりません")
-		return xerrors.New("CutKeyDownHandler ATEMが見つかりません")
-	}
-
-	a.logger.Debug(ctx, "CutKeyDownHandler")
-
-	instance.Client.PerformCut()
-	a.logger.Debug(ctx, "CutKeyDownHandler 完了")
-	return nil
-}
-
-// CutDidReceiveSettingsHandler Cutの設定を受け取る
-func (a *App) CutDidReceiveSettingsHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
-	var payload streamdeck.DidReceiveSettingsPayload[AutoPropertyInspector]
-	if err := json.Unmarshal(event.Payload, &payload); err != nil {
-		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
-		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
-	}
-
-	// 新しいインスタンスを初期化
-	if err := a.addATEMHost(ctx, cutAction, event.Context, payload.Settings.IP, true); err != nil {
-		return xerrors.Errorf("ATEMホストの追加に失敗: %w", err)
-	}
-
-	return nil
+// cutKeyDown ATEM Cutを実行
+func (a *App) cutKeyDown(ctx context.Context, instance *connectionmanager.ATEMInstance, p *cutPropertyInspector) error {
+	return a.programCommand(instance, asrun.OriginDeck, func() error {
+		return instance.Client.PerformCut(0)
+	})
 }
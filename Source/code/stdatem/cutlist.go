@@ -0,0 +1,172 @@
+package stdatem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/atemclient"
+	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+	"github.com/FlowingSPDG/std-atem/Source/code/cutlist"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"github.com/FlowingSPDG/streamdeck"
+	sdcontext "github.com/FlowingSPDG/streamdeck/context"
+	"golang.org/x/xerrors"
+)
+
+// cutListDir カットリストの既定の書き出し先。プラグインのフォルダからの相対パス
+const cutListDir = "cutlists"
+
+const (
+	// cutListCommandExport カットリストを書き出す
+	cutListCommandExport = "exportCutList"
+	// cutListCommandClear カットリストを消去する
+	cutListCommandClear = "clearCutList"
+)
+
+// cutListCommand Property Inspectorから送信されるカットリスト操作
+type cutListCommand struct {
+	Command string `json:"command"`
+	Format  string `json:"format"`
+	Path    string `json:"path"`
+	MeIndex uint8  `json:"me"`
+	FPS     int    `json:"fps"` // カットを記録したフレームレートが不明な場合に利用する
+}
+
+// cutListResult Property Inspectorに返すカットリスト操作の結果
+type cutListResult struct {
+	Event string `json:"event"`
+	Path  string `json:"path,omitempty"`
+	Cuts  int    `json:"cuts"` // 書き出した、または消去したカットの数
+	Error string `json:"error,omitempty"`
+}
+
+// recordCut PGMの変化をスイッチャーのカットリストに記録する
+func (a *App) recordCut(instance *connectionmanager.ATEMInstance, ev state.Event) {
+	changed, ok := ev.(state.ProgramChanged)
+	if !ok {
+		return
+	}
+	history, _ := a.cutLists.LoadOrCompute(instance.Client.IP(), func() cutlist.History {
+		return cutlist.NewHistory(0)
+	})
+	name := state.InputName(instance.State, changed.Input)
+	cut := cutlist.Cut{
+		Time:    time.Now(),
+		MeIndex: changed.MeIndex,
+		Input:   changed.Input,
+		Name:    name.LongName,
+		Reel:    cutlist.ReelName(name.ShortName, changed.Input),
+	}
+	if timecode, fps, ok := instance.Client.Timecode(); ok {
+		cut.Timecode = timecode
+		cut.TimecodeSource = atemclient.TimecodeSourceHostClock
+		cut.FPS = fps
+	}
+	history.Add(cut)
+}
+
+// CutListSendToPluginHandler Property Inspectorからのカットリスト操作を処理する
+// 対象はPropertyInspectorを開いているボタンが接続しているスイッチャー
+func (a *App) CutListSendToPluginHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
+	var cmd cutListCommand
+	if err := json.Unmarshal(event.Payload, &cmd); err != nil {
+		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
+		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
+	}
+
+	var result cutListResult
+	var err error
+	host := ""
+	if e, ok := a.registry.Load(event.Context); ok {
+		host = e.Host
+	}
+	switch cmd.Command {
+	case cutListCommandExport:
+		result.Event = "cutListExported"
+		result.Path, result.Cuts, err = a.exportCutList(ctx, host, cmd)
+	case cutListCommandClear:
+		result.Event = "cutListCleared"
+		result.Cuts, err = a.clearCutList(ctx, host)
+	default:
+		return nil
+	}
+
+	sdctx := sdcontext.WithContext(ctx, event.Context)
+	if err != nil {
+		a.logger.Error(ctx, "カットリストの%sに失敗: %v", cmd.Command, err)
+		result.Error = err.Error()
+		a.sd.ShowAlert(sdctx)
+	} else {
+		a.sd.ShowOk(sdctx)
+	}
+	if err := a.sd.SendToPropertyInspector(sdctx, result); err != nil {
+		a.logger.Error(ctx, "Property Inspectorへの送信に失敗: %v", err)
+		a.sdSendFailed(streamdeck.SendToPropertyInspector)
+	}
+	return nil
+}
+
+// exportCutList hostのM/Eの履歴をpathに書き出す。pathが空の場合はcutlists以下に書き出す
+func (a *App) exportCutList(ctx context.Context, host string, cmd cutListCommand) (string, int, error) {
+	if host == "" {
+		return "", 0, xerrors.New("スイッチャーに接続していません")
+	}
+	history, ok := a.cutLists.Load(host)
+	if !ok {
+		return "", 0, xerrors.New("記録されたカットがありません")
+	}
+	cuts := history.Cuts(cmd.MeIndex)
+	if len(cuts) == 0 {
+		return "", 0, xerrors.New("記録されたカットがありません")
+	}
+
+	format := cutlist.Format(cmd.Format)
+	if format == "" {
+		format = cutlist.FormatEDL
+	}
+	// 空のファイルを残さないよう、作成前に確認する
+	if !format.Valid() {
+		return "", 0, xerrors.Errorf("書き出し形式 %q には対応していません", format)
+	}
+	if format == cutlist.FormatEDL && len(cuts) > cutlist.MaxEDLEvents {
+		return "", 0, xerrors.Errorf("EDLに書き出せるカットは%d個までです (%d個)。CSVかFCPXMLで書き出してください", cutlist.MaxEDLEvents, len(cuts))
+	}
+	now := time.Now()
+	path := a.pluginPath(cmd.Path, filepath.Join(cutListDir, fmt.Sprintf("cutlist-%s%s", now.Format("20060102-150405"), format.Ext())))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", 0, xerrors.Errorf("ディレクトリの作成に失敗: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", 0, xerrors.Errorf("ファイルの作成に失敗: %w", err)
+	}
+	defer f.Close()
+
+	title := fmt.Sprintf("%s ME%d %s", host, cmd.MeIndex+1, now.Format("2006-01-02"))
+	if err := cutlist.Write(f, format, title, cuts, now, cmd.FPS); err != nil {
+		return "", 0, xerrors.Errorf("カットリストの書き出しに失敗: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", 0, xerrors.Errorf("ファイルのクローズに失敗: %w", err)
+	}
+	a.logger.Info(ctx, "カットリストを %s に書き出しました (カット %d 個)", path, len(cuts))
+	return path, len(cuts), nil
+}
+
+// clearCutList hostの履歴を消去し、消去したカットの数を返す
+func (a *App) clearCutList(ctx context.Context, host string) (int, error) {
+	if host == "" {
+		return 0, xerrors.New("スイッチャーに接続していません")
+	}
+	history, ok := a.cutLists.Load(host)
+	if !ok {
+		return 0, nil
+	}
+	n := history.Reset()
+	a.logger.Info(ctx, "%s のカットリストを消去しました (カット %d 個)", host, n)
+	return n, nil
+}
@@ -0,0 +1,189 @@
+package stdatem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+	"github.com/FlowingSPDG/std-atem/Source/code/diag"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"github.com/FlowingSPDG/streamdeck"
+	sdcontext "github.com/FlowingSPDG/streamdeck/context"
+	"golang.org/x/xerrors"
+)
+
+// supportBundleDir 診断データの既定の書き出し先。プラグインのフォルダからの相対パス
+const supportBundleDir = "support"
+
+const (
+	// diagnosticsCommandGet 診断情報を取得する
+	diagnosticsCommandGet = "getDiagnostics"
+	// diagnosticsCommandExport 診断データを書き出す
+	diagnosticsCommandExport = "exportSupportBundle"
+)
+
+// diagnosticsCommand Property Inspectorから送信される診断操作
+type diagnosticsCommand struct {
+	Command string `json:"command"`
+	Path    string `json:"path"`
+}
+
+// diagnosticsResult Property Inspectorに返す診断操作の結果
+type diagnosticsResult struct {
+	Event  string       `json:"event"`
+	Report *diag.Report `json:"report,omitempty"`
+	Path   string       `json:"path,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// diagnosticsActionDef 接続の状態を表示し、押下で診断データを書き出すアクション
+func (a *App) diagnosticsActionDef() actionDef[DiagnosticsPropertyInspector, *diagnosticsPropertyInspector] {
+	return actionDef[DiagnosticsPropertyInspector, *diagnosticsPropertyInspector]{
+		uuid:     diagnosticsAction,
+		name:     "Diagnostics",
+		defaults: diagnosticsSettingsDefaults,
+		parse:    (*DiagnosticsPropertyInspector).Parse,
+		keyDown:  a.diagnosticsKeyDown,
+		render:   a.renderDiagnostics,
+		events: func(*diagnosticsPropertyInspector) []state.EventType {
+			return []state.EventType{state.EventConnection}
+		},
+		// 接続できない時こそ診断データが必要なため、スイッチャーの状態に関わらず書き出す
+		local: true,
+	}
+}
+
+// diagnosticsKeyDown 診断データを既定の書き出し先に書き出す。instanceは常にnil
+// Stream Deckのイベントのctxには押下されたボタンのContextが含まれる
+func (a *App) diagnosticsKeyDown(ctx context.Context, _ *connectionmanager.ATEMInstance, p *diagnosticsPropertyInspector) error {
+	path, err := a.exportSupportBundle(ctx, "")
+	if err != nil {
+		a.sd.ShowAlert(ctx)
+		return err
+	}
+	a.logger.Info(ctx, "診断データを %s に書き出しました", path)
+	a.sd.ShowOk(ctx)
+	return nil
+}
+
+// renderDiagnostics 接続の状態と製品名をタイトルに表示する
+func (a *App) renderDiagnostics(ctx context.Context, contextID string, p *diagnosticsPropertyInspector, st state.Switcher) {
+	title := "Offline"
+	if st.Connected() {
+		title = "Online"
+		if instance, ok := a.connectionManager.SolveATEMByContext(ctx, contextID); ok {
+			if stats, ok := instance.Client.Stats(); ok {
+				title += "\n" + stats.ProductName
+			}
+		}
+	}
+	if err := a.sd.SetTitle(sdcontext.WithContext(ctx, contextID), title, streamdeck.HardwareAndSoftware); err != nil {
+		a.logger.Error(ctx, "タイトルの設定に失敗: %v", err)
+		a.sdSendFailed(streamdeck.SetTitle)
+	}
+}
+
+// DiagnosticsSendToPluginHandler Property Inspectorからの診断操作を処理する
+func (a *App) DiagnosticsSendToPluginHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
+	var cmd diagnosticsCommand
+	if err := json.Unmarshal(event.Payload, &cmd); err != nil {
+		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
+		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
+	}
+
+	sdctx := sdcontext.WithContext(ctx, event.Context)
+	var result diagnosticsResult
+	switch cmd.Command {
+	case diagnosticsCommandGet:
+		// 定期的に取得されるため、ボタンには表示しない
+		report := a.diagnosticsReport(ctx)
+		result.Event = "diagnostics"
+		result.Report = &report
+	case diagnosticsCommandExport:
+		result.Event = "supportBundleExported"
+		path, err := a.exportSupportBundle(ctx, cmd.Path)
+		if err != nil {
+			a.logger.Error(ctx, "診断データの書き出しに失敗: %v", err)
+			result.Error = err.Error()
+			a.sd.ShowAlert(sdctx)
+		} else {
+			a.logger.Info(ctx, "診断データを %s に書き出しました", path)
+			result.Path = path
+			a.sd.ShowOk(sdctx)
+		}
+	default:
+		return nil
+	}
+
+	if err := a.sd.SendToPropertyInspector(sdctx, result); err != nil {
+		a.logger.Error(ctx, "Property Inspectorへの送信に失敗: %v", err)
+		a.sdSendFailed(streamdeck.SendToPropertyInspector)
+	}
+	return nil
+}
+
+// diagnosticsReport 接続中の全てのスイッチャーの診断情報を集計する
+func (a *App) diagnosticsReport(ctx context.Context) diag.Report {
+	report := diag.Report{GeneratedAt: time.Now()}
+	a.connectionManager.Range(func(host string, instance *connectionmanager.ATEMInstance, contexts []connectionmanager.ActionAndContext) bool {
+		s := diag.Switcher{
+			Host:      host,
+			Connected: instance.State.Connected(),
+			Link:      a.tracker.Link(host),
+			Contexts:  make([]diag.Context, 0, len(contexts)),
+		}
+		if stats, ok := instance.Client.Stats(); ok {
+			s.SetStats(stats)
+		}
+		for _, c := range contexts {
+			dc := diag.Context{Context: c.Context, Action: c.Action}
+			if e, ok := a.registry.Load(c.Context); ok {
+				dc.Switcher = e.Switcher
+			}
+			s.Contexts = append(s.Contexts, dc)
+		}
+		report.Switchers = append(report.Switchers, s)
+		return true
+	})
+	sort.Slice(report.Switchers, func(i, j int) bool {
+		return report.Switchers[i].Host < report.Switchers[j].Host
+	})
+	return report
+}
+
+// exportSupportBundle 診断データをpathに書き出す。pathが空の場合はsupport以下に書き出す
+func (a *App) exportSupportBundle(ctx context.Context, path string) (string, error) {
+	path = a.pluginPath(path, filepath.Join(supportBundleDir, fmt.Sprintf("support-%s.zip", time.Now().Format("20060102-150405"))))
+
+	bundle := diag.Bundle{
+		Report:    a.diagnosticsReport(ctx),
+		Settings:  a.globalSettings.Load().Sanitized(),
+		Snapshots: map[string]state.Snapshot{},
+		LogDir:    a.logDir,
+	}
+	a.connectionManager.Range(func(host string, instance *connectionmanager.ATEMInstance, _ []connectionmanager.ActionAndContext) bool {
+		bundle.Snapshots[host] = instance.State.Snapshot()
+		return true
+	})
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", xerrors.Errorf("ディレクトリの作成に失敗: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", xerrors.Errorf("ファイルの作成に失敗: %w", err)
+	}
+	defer f.Close()
+	if err := diag.WriteBundle(f, bundle); err != nil {
+		return "", xerrors.Errorf("診断データの書き出しに失敗: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", xerrors.Errorf("ファイルのクローズに失敗: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,25 @@
+package stdatem
+
+import (
+	"context"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+)
+
+// ftbActionDef ATEM FTBを切り替えるアクション
+func (a *App) ftbActionDef() actionDef[FTBPropertyInspector, *ftbPropertyInspector] {
+	return actionDef[FTBPropertyInspector, *ftbPropertyInspector]{
+		uuid:     ftbAction,
+		name:     "FTB",
+		defaults: transitionSettingsDefaults,
+		parse:    (*FTBPropertyInspector).Parse,
+		keyDown:  a.ftbKeyDown,
+		// FTBの状態を反映の確認に使っていないため、送信しても完了は表示しない
+		control: true,
+	}
+}
+
+// ftbKeyDown ATEM FTBを切り替え
+func (a *App) ftbKeyDown(ctx context.Context, instance *connectionmanager.ATEMInstance, p *ftbPropertyInspector) error {
+	return instance.Client.PerformFadeToBlack(0)
+}
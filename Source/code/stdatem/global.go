@@ -0,0 +1,138 @@
+package stdatem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/logger"
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+	"github.com/FlowingSPDG/streamdeck"
+	sdcontext "github.com/FlowingSPDG/streamdeck/context"
+	"golang.org/x/xerrors"
+)
+
+// requestGlobalSettings グローバル設定の取得を一度だけ要求する
+func (a *App) requestGlobalSettings(ctx context.Context) {
+	a.globalSettingsOnce.Do(func() {
+		sdctx := sdcontext.WithContext(ctx, a.pluginUUID)
+		if err := a.sd.GetGlobalSettings(sdctx); err != nil {
+			a.logger.Error(ctx, "グローバル設定の取得に失敗: %v", err)
+		}
+	})
+}
+
+// resolveHost 名前付きスイッチャーまたはIPから接続先ホストを解決する
+// 名前付きスイッチャーが見つからない場合はボタンごとのIPにフォールバックする
+func (a *App) resolveHost(switcher, ip string) (string, bool) {
+	if switcher != "" {
+		if profile, ok := a.globalSettings.Load().Switcher(switcher); ok && profile.Address != "" {
+			return profile.Host(), true
+		}
+	}
+	return ip, ip != ""
+}
+
+// bindContext Contextを接続先スイッチャーに紐付ける
+func (a *App) bindContext(ctx context.Context, action, contextID, switcher, ip string, debug bool) error {
+	a.requestGlobalSettings(ctx)
+
+	host, ok := a.resolveHost(switcher, ip)
+	a.registry.Update(contextID, func(e *setting.Entry) {
+		e.Switcher = switcher
+		e.IP = ip
+		e.Host = host
+	})
+	if !ok {
+		// グローバル設定の受信後に再度紐付ける
+		a.logger.Warn(ctx, "スイッチャー %q の接続先が見つかりません contextID:%s", switcher, contextID)
+		a.connectionManager.DeleteATEMByContext(ctx, contextID)
+		return nil
+	}
+
+	if err := a.addATEMHost(ctx, action, contextID, host, debug); err != nil {
+		return xerrors.Errorf("ATEMホストの追加に失敗: %w", err)
+	}
+	return nil
+}
+
+// DidReceiveGlobalSettingsHandler グローバル設定を受け取り、接続先が変化したContextを再接続してサービスに反映する
+func (a *App) DidReceiveGlobalSettingsHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
+	var payload streamdeck.DidReceiveGlobalSettingsPayload[setting.GlobalSettings]
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
+		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
+	}
+	a.logger.Debug(ctx, "グローバル設定を受信 %#v", payload.Settings)
+	a.globalSettings.Store(&payload.Settings)
+
+	a.applyLogLevel(ctx)
+	a.rebindAll(ctx)
+	a.applyServices(ctx)
+	a.applyLock(ctx)
+	return nil
+}
+
+// updateGlobalSettings 現在のグローバル設定のコピーをfで変更して保存する
+// グローバル設定はプラグインから設定してもdidReceiveGlobalSettingsが届かないため、保存した設定を直接反映する
+func (a *App) updateGlobalSettings(ctx context.Context, f func(gs *setting.GlobalSettings)) error {
+	gs := &setting.GlobalSettings{}
+	if current := a.globalSettings.Load(); current != nil {
+		*gs = *current
+	}
+	f(gs)
+	if err := a.sd.SetGlobalSettings(sdcontext.WithContext(ctx, a.pluginUUID), gs); err != nil {
+		a.sdSendFailed(streamdeck.SetGlobalSettings)
+		return xerrors.Errorf("グローバル設定の保存に失敗: %w", err)
+	}
+	a.globalSettings.Store(gs)
+	return nil
+}
+
+// applyLogLevel グローバル設定のログレベルをロガーに反映する
+func (a *App) applyLogLevel(ctx context.Context) {
+	level, err := logger.ParseLevel(a.globalSettings.Load().LogSettings().Level)
+	if err != nil {
+		a.logger.Warn(ctx, "ログレベルの反映に失敗: %v", err)
+	}
+	a.logger.SetLevel(level)
+}
+
+// rebindAll 名前付きスイッチャーを参照する全てのContextを、現在のグローバル設定で紐付け直す
+func (a *App) rebindAll(ctx context.Context) {
+	a.registry.Range(func(e setting.Entry) bool {
+		if e.Switcher == "" {
+			return true
+		}
+		host, ok := a.resolveHost(e.Switcher, e.IP)
+		if host == e.Host {
+			return true
+		}
+
+		a.logger.Info(ctx, "スイッチャー %s の接続先を %q から %q に変更 contextID:%s", e.Switcher, e.Host, host, e.Context)
+		a.connectionManager.DeleteATEMByContext(ctx, e.Context)
+		a.registry.Update(e.Context, func(e *setting.Entry) {
+			e.Host = host
+		})
+		if !ok {
+			return true
+		}
+		if err := a.addATEMHost(ctx, e.Action, e.Context, host, false); err != nil {
+			a.logger.Error(ctx, "ATEMホストの追加に失敗: %v", err)
+			return true
+		}
+		a.renderContext(ctx, e.Action, e.Context)
+		return true
+	})
+}
+
+// renderContext Contextのアクションに応じてキャッシュ済みの状態を描画する
+func (a *App) renderContext(ctx context.Context, action, contextID string) {
+	instance, ok := a.connectionManager.SolveATEMByContext(ctx, contextID)
+	if !ok {
+		return
+	}
+	if act, ok := a.actions[action]; ok {
+		act.render(ctx, contextID, instance.State)
+	}
+}
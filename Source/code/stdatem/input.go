@@ -0,0 +1,93 @@
+package stdatem
+
+import (
+	"context"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/asrun"
+	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"github.com/FlowingSPDG/streamdeck"
+	sdcontext "github.com/FlowingSPDG/streamdeck/context"
+)
+
+// inputActionDef PGM/PVW両方のタリーを表示するアクション
+func (a *App) inputActionDef() actionDef[InputPropertyInspector, *inputPropertyInspector] {
+	return actionDef[InputPropertyInspector, *inputPropertyInspector]{
+		uuid:     inputAction,
+		name:     "Input",
+		defaults: inputSettingsDefaults,
+		parse:    (*InputPropertyInspector).Parse,
+		keyDown:  a.inputKeyDown,
+		control:  true,
+		render:   a.renderInputTally,
+		events: func(*inputPropertyInspector) []state.EventType {
+			return []state.EventType{state.EventPreview, state.EventProgram}
+		},
+		expect: expectInput,
+	}
+}
+
+// expectInput inputKeyDownと同じ判定で、押下モードに応じた反映を待つ
+func expectInput(p *inputPropertyInspector, st state.Switcher) ackMatcher {
+	input := uint16(p.Input)
+	switch p.PressMode {
+	case inputPressProgram:
+		return expectProgram(st, p.MeIndex, input)
+	case inputPressPreviewThenCut:
+		if preview, ok := st.Preview(p.MeIndex); ok && preview == input {
+			return expectCut(st, p.MeIndex)
+		}
+		return expectPreview(st, p.MeIndex, input)
+	default:
+		return expectPreview(st, p.MeIndex, input)
+	}
+}
+
+// inputKeyDown 押下モードに応じてPVW/PGMの設定、またはCutを実行
+func (a *App) inputKeyDown(ctx context.Context, instance *connectionmanager.ATEMInstance, p *inputPropertyInspector) error {
+	a.logger.Debug(ctx, "inputKeyDown input:%d meIndex:%d pressMode:%s", p.Input, p.MeIndex, p.PressMode)
+
+	switch p.PressMode {
+	case inputPressProgram:
+		return a.programCommand(instance, asrun.OriginDeck, func() error {
+			return instance.Client.SetProgramInput(p.Input, p.MeIndex)
+		})
+	case inputPressPreviewThenCut:
+		// 既にPVWに設定されていればCutし、そうでなければPVWに設定する
+		if preview, ok := instance.State.Preview(p.MeIndex); ok && preview == uint16(p.Input) {
+			return a.programCommand(instance, asrun.OriginDeck, func() error {
+				return instance.Client.PerformCut(p.MeIndex)
+			})
+		}
+		return instance.Client.SetPreviewInput(p.Input, p.MeIndex)
+	default:
+		return instance.Client.SetPreviewInput(p.Input, p.MeIndex)
+	}
+}
+
+// renderInputTally 状態キャッシュからInputのタリーを描画する
+// PGMは赤、PVWは緑、PGMとPVWの両方に載っている場合は黄色
+// ATEMクライアントはトランジションの状態を通知しないため、トランジション中であることは表示しない
+func (a *App) renderInputTally(ctx context.Context, contextID string, p *inputPropertyInspector, st state.Switcher) {
+	image := tallyInactive
+	if me, ok := st.MixEffect(p.MeIndex); ok {
+		input := uint16(p.Input)
+		onProgram := me.Program == input
+		onPreview := me.Preview == input
+		switch {
+		case onProgram && onPreview:
+			image = tallyTransition
+		case onProgram:
+			image = tallyProgram
+		case onPreview:
+			image = tallyPreview
+		}
+	}
+	a.logger.Debug(ctx, "renderInputTally setting:%v", p)
+
+	sdctx := sdcontext.WithContext(ctx, contextID)
+	if err := a.sd.SetImage(sdctx, image, streamdeck.HardwareAndSoftware); err != nil {
+		a.logger.Error(ctx, "renderInputTally 画像の設定に失敗: %v", err)
+		a.sdSendFailed(streamdeck.SetImage)
+	}
+}
@@ -0,0 +1,234 @@
+package stdatem
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/api"
+	"github.com/FlowingSPDG/std-atem/Source/code/atemsim"
+	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"github.com/FlowingSPDG/streamdeck"
+	sdcontext "github.com/FlowingSPDG/streamdeck/context"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// rehearsalServiceName リハーサル用のスイッチャーとの接続を保持するサービス名
+	rehearsalServiceName = "rehearsal"
+	// rehearsalSimAddress 内蔵シミュレーターの待ち受けアドレス。ポートはOSが割り当てる
+	rehearsalSimAddress = "127.0.0.1:0"
+)
+
+// lockOverlayTitles スイッチャーを操作するボタンに重ねて表示するタイトル
+var lockOverlayTitles = map[setting.LockMode]string{
+	setting.LockModeOff:       "",
+	setting.LockModeLocked:    "LOCKED",
+	setting.LockModeRehearsal: "REHEARSAL",
+}
+
+// lockActionDef パネルロック・リハーサルモードを切り替えるアクション
+func (a *App) lockActionDef() actionDef[LockPropertyInspector, *lockPropertyInspector] {
+	return actionDef[LockPropertyInspector, *lockPropertyInspector]{
+		uuid:       lockAction,
+		name:       "Lock",
+		defaults:   lockSettingsDefaults,
+		parse:      (*LockPropertyInspector).Parse,
+		keyDown:    a.lockKeyDown,
+		render:     a.renderLock,
+		standalone: true,
+	}
+}
+
+// lockKeyDown ロック中であれば解除し、そうでなければボタンに設定されたモードにする
+func (a *App) lockKeyDown(ctx context.Context, _ *connectionmanager.ATEMInstance, p *lockPropertyInspector) error {
+	mode := p.Mode
+	if a.globalSettings.Load().LockSettings().Mode != setting.LockModeOff {
+		mode = setting.LockModeOff
+	}
+	if err := a.setLockMode(ctx, mode); err != nil {
+		return xerrors.Errorf("パネルロックの切り替えに失敗: %w", err)
+	}
+	return nil
+}
+
+// renderLock 現在のモードをボタンに表示する
+func (a *App) renderLock(ctx context.Context, contextID string, p *lockPropertyInspector, _ state.Switcher) {
+	mode := a.globalSettings.Load().LockSettings().Mode
+	image, title := tallyInactive, "UNLOCKED"
+	switch mode {
+	case setting.LockModeLocked:
+		image, title = tallyProgram, lockOverlayTitles[mode]
+	case setting.LockModeRehearsal:
+		image, title = tallyTransition, lockOverlayTitles[mode]
+	}
+
+	sdctx := sdcontext.WithContext(ctx, contextID)
+	if err := a.sd.SetImage(sdctx, image, streamdeck.HardwareAndSoftware); err != nil {
+		a.logger.Error(ctx, "renderLock 画像の設定に失敗: %v", err)
+		a.sdSendFailed(streamdeck.SetImage)
+	}
+	if err := a.sd.SetTitle(sdctx, title, streamdeck.HardwareAndSoftware); err != nil {
+		a.logger.Error(ctx, "renderLock タイトルの設定に失敗: %v", err)
+		a.sdSendFailed(streamdeck.SetTitle)
+	}
+}
+
+// renderLockOverlay スイッチャーを操作するボタンにモードを重ねて表示する
+// ロックしていない場合は、resetがtrueであればユーザーが設定したタイトルに戻し、falseであれば何もしない
+func (a *App) renderLockOverlay(ctx context.Context, contextID string, mode setting.LockMode, reset bool) {
+	title := lockOverlayTitles[mode]
+	if title == "" {
+		if !reset {
+			return
+		}
+		if e, ok := a.registry.Load(contextID); ok {
+			title = e.Title
+		}
+	}
+	if err := a.sd.SetTitle(sdcontext.WithContext(ctx, contextID), title, streamdeck.HardwareAndSoftware); err != nil {
+		a.logger.Error(ctx, "ロック表示の設定に失敗: %v", err)
+		a.sdSendFailed(streamdeck.SetTitle)
+	}
+}
+
+// isLockOverlayTitle ロック表示のために設定したタイトルであればtrueを返す
+func isLockOverlayTitle(title string) bool {
+	for _, t := range lockOverlayTitles {
+		if t != "" && t == title {
+			return true
+		}
+	}
+	return false
+}
+
+// TitleParametersDidChangeHandler ユーザーが設定したタイトルを記録する
+// setTitleによる変更でも通知されるため、ロック表示のタイトルは記録しない
+func (a *App) TitleParametersDidChangeHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
+	var payload struct {
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		a.logger.Error(ctx, "payloadのアンマーシャルに失敗: %v", err)
+		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
+	}
+	if isLockOverlayTitle(payload.Title) {
+		return nil
+	}
+	a.registry.Update(event.Context, func(e *setting.Entry) {
+		e.Title = payload.Title
+	})
+	return nil
+}
+
+// setLockMode モードをグローバル設定に保存して反映する
+func (a *App) setLockMode(ctx context.Context, mode setting.LockMode) error {
+	if err := a.updateGlobalSettings(ctx, func(gs *setting.GlobalSettings) {
+		lock := gs.LockSettings()
+		lock.Mode = mode
+		gs.Lock = &lock
+	}); err != nil {
+		return err
+	}
+	a.applyServices(ctx)
+	a.applyLock(ctx)
+	return nil
+}
+
+// applyLock モードが変化していれば、全てのボタンの表示を更新する
+func (a *App) applyLock(ctx context.Context) {
+	mode := a.globalSettings.Load().LockSettings().Mode
+	prev, _ := a.lockMode.Swap(mode).(setting.LockMode)
+	if prev == mode {
+		return
+	}
+	a.logger.Info(ctx, "パネルロックを %q から %q に変更", prev, mode)
+
+	a.registry.Range(func(e setting.Entry) bool {
+		if act, ok := a.actions[e.Action]; ok {
+			act.lockChanged(ctx, e.Context, mode)
+		}
+		return true
+	})
+}
+
+// routeCommand モードに応じてボタンや外部インターフェースからの操作の送信先を決定する
+func (a *App) routeCommand(ctx context.Context, instance *connectionmanager.ATEMInstance) (*connectionmanager.ATEMInstance, error) {
+	switch a.globalSettings.Load().LockSettings().Mode {
+	case setting.LockModeLocked:
+		return nil, api.ErrLocked
+	case setting.LockModeRehearsal:
+		rehearsal, ok := a.connectionManager.SolveATEMByContext(ctx, serviceContextPrefix+rehearsalServiceName)
+		if !ok {
+			return nil, xerrors.Errorf("リハーサル用のスイッチャー: %w", api.ErrSwitcherNotFound)
+		}
+		return rehearsal, nil
+	}
+	return instance, nil
+}
+
+// rehearsalService 起動中のリハーサル用のスイッチャー
+type rehearsalService struct {
+	settings setting.LockSettings
+	sim      atemsim.Server // 送信先を指定していない場合に起動したシミュレーター
+}
+
+// applyRehearsal グローバル設定に従ってリハーサル用のスイッチャーに接続・切断する
+func (a *App) applyRehearsal(ctx context.Context) {
+	settings := a.globalSettings.Load().LockSettings()
+
+	if a.rehearsal != nil && a.rehearsal.settings != settings {
+		a.stopRehearsal(ctx)
+	}
+	if settings.Mode != setting.LockModeRehearsal || a.rehearsal != nil {
+		return
+	}
+
+	svc := &rehearsalService{settings: settings}
+	target := settings.Rehearsal
+	if target == "" {
+		// 同じPCの実機やATEM Software Controlと衝突しないよう、空いているポートで起動する
+		// ATEMクライアントは"host:port"の形式で任意のポートに接続できる
+		sim, err := atemsim.NewServer(atemsim.Config{
+			Address: rehearsalSimAddress,
+			Logger:  a.logger.With("service", "atemsim"),
+		})
+		if err != nil {
+			a.logger.Error(ctx, "リハーサル用のシミュレーターの起動に失敗: %v", err)
+			return
+		}
+		go func() {
+			if err := sim.Run(ctx); err != nil {
+				a.logger.Error(ctx, "リハーサル用のシミュレーターが終了しました: %v", err)
+			}
+		}()
+		svc.sim = sim
+		target = sim.Addr().String()
+	}
+
+	if _, ok := a.acquireSwitcher(ctx, rehearsalServiceName, target); !ok {
+		a.logger.Error(ctx, "リハーサル用のスイッチャー %q が見つかりません", target)
+		if svc.sim != nil {
+			svc.sim.Close()
+		}
+		return
+	}
+	a.logger.Info(ctx, "リハーサル中の操作を %s に送信", target)
+	a.rehearsal = svc
+}
+
+// stopRehearsal リハーサル用のスイッチャーとの接続を解除し、シミュレーターを停止する
+func (a *App) stopRehearsal(ctx context.Context) {
+	if a.rehearsal == nil {
+		return
+	}
+	a.releaseSwitcher(ctx, rehearsalServiceName)
+	if a.rehearsal.sim != nil {
+		if err := a.rehearsal.sim.Close(); err != nil {
+			a.logger.Warn(ctx, "リハーサル用のシミュレーターの停止に失敗: %v", err)
+		}
+	}
+	a.rehearsal = nil
+	a.logger.Info(ctx, "リハーサル用のスイッチャーとの接続を解除")
+}
@@ -0,0 +1,114 @@
+package stdatem
+
+import (
+	"context"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+	"github.com/FlowingSPDG/std-atem/Source/code/metrics"
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+)
+
+// appMetrics プラグイン全体のメトリクス
+type appMetrics struct {
+	registry       metrics.Registry
+	keyPresses     metrics.Counter   // アクションごとのボタンの押下
+	confirmation   metrics.Histogram // 操作の送信から反映の確認まで
+	reconnects     metrics.Counter   // スイッチャーごとの接続の試行
+	sdSendFailures metrics.Counter   // Stream Deckへの送信の失敗
+}
+
+// newAppMetrics メトリクスを登録する。スイッチャーごとの値は出力のたびにaから集計する
+func newAppMetrics(a *App) *appMetrics {
+	r := metrics.NewRegistry()
+	m := &appMetrics{
+		registry: r,
+		keyPresses: r.Counter("stdatem_key_presses_total",
+			"Number of key presses per action.", "action"),
+		confirmation: r.Histogram("stdatem_command_confirmation_seconds",
+			"Time from sending a command to receiving the resulting state change.", metrics.DefaultBuckets, "action"),
+		reconnects: r.Counter("stdatem_reconnect_attempts_total",
+			"Number of connection attempts per switcher.", "switcher"),
+		sdSendFailures: r.Counter("stdatem_streamdeck_send_failures_total",
+			"Number of failed messages to the Stream Deck software.", "event"),
+	}
+	r.CounterFunc("stdatem_dropped_events_total",
+		"Number of state events dropped because a subscriber could not keep up.", []string{"switcher"},
+		func(emit metrics.Emit) {
+			a.connectionManager.Range(func(host string, instance *connectionmanager.ATEMInstance, _ []connectionmanager.ActionAndContext) bool {
+				emit(float64(instance.State.Dropped()), host)
+				return true
+			})
+		})
+	r.GaugeFunc("stdatem_switcher_connected",
+		"Whether the switcher is connected (1) or not (0).", []string{"switcher"},
+		func(emit metrics.Emit) {
+			a.connectionManager.Range(func(host string, instance *connectionmanager.ATEMInstance, _ []connectionmanager.ActionAndContext) bool {
+				v := 0.0
+				if instance.State.Connected() {
+					v = 1
+				}
+				emit(v, host)
+				return true
+			})
+		})
+	r.GaugeFunc("stdatem_contexts",
+		"Number of buttons and services bound to the switcher.", []string{"switcher"},
+		func(emit metrics.Emit) {
+			a.connectionManager.Range(func(host string, _ *connectionmanager.ATEMInstance, contexts []connectionmanager.ActionAndContext) bool {
+				emit(float64(len(contexts)), host)
+				return true
+			})
+		})
+	return m
+}
+
+// sdSendFailed Stream Deckへの送信の失敗を記録する
+func (a *App) sdSendFailed(event string) {
+	a.metrics.sdSendFailures.Inc(event)
+}
+
+// metricsService 起動中のメトリクスのHTTPサーバー
+type metricsService struct {
+	settings setting.MetricsSettings
+	server   metrics.Server
+}
+
+// applyMetrics グローバル設定に従ってメトリクスのHTTPサーバーを起動・停止する
+func (a *App) applyMetrics(ctx context.Context) {
+	settings := a.globalSettings.Load().MetricsSettings()
+
+	if a.metricsServer != nil && a.metricsServer.settings != settings {
+		a.stopMetrics(ctx)
+	}
+	if !settings.Enabled || a.metricsServer != nil {
+		return
+	}
+
+	server, err := metrics.NewServer(metrics.Config{
+		Address:  settings.Address,
+		Registry: a.metrics.registry,
+	})
+	if err != nil {
+		a.logger.Error(ctx, "メトリクスの公開に失敗: %v", err)
+		return
+	}
+	a.logger.Info(ctx, "メトリクスを公開 http://%s/metrics", server.Addr())
+	go func() {
+		if err := server.Run(ctx); err != nil {
+			a.logger.Error(ctx, "メトリクスのHTTPサーバーが終了しました: %v", err)
+		}
+	}()
+	a.metricsServer = &metricsService{settings: settings, server: server}
+}
+
+// stopMetrics メトリクスのHTTPサーバーを停止する
+func (a *App) stopMetrics(ctx context.Context) {
+	if a.metricsServer == nil {
+		return
+	}
+	if err := a.metricsServer.server.Close(); err != nil {
+		a.logger.Warn(ctx, "メトリクスのHTTPサーバーの停止に失敗: %v", err)
+	}
+	a.metricsServer = nil
+	a.logger.Info(ctx, "メトリクスの公開を停止")
+}
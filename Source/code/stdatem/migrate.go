@@ -0,0 +1,161 @@
+package stdatem
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+	"github.com/FlowingSPDG/streamdeck"
+	sdcontext "github.com/FlowingSPDG/streamdeck/context"
+	"golang.org/x/xerrors"
+)
+
+// settingsVersion 現在のボタン設定のスキーマバージョン
+// 設定の項目を追加・変更した場合はバージョンを上げ、migrationsに移行処理を追加する
+const settingsVersion = 3
+
+// migration 設定を1つ後のバージョンに移行する
+type migration func(settings map[string]any, defaults map[string]any)
+
+// migrations migrations[i]はバージョンiからi+1への移行処理
+var migrations = []migration{
+	migrateV0,
+	migrateV1,
+	migrateV2,
+}
+
+// migrateV0 バージョン管理導入前の設定に、未設定の項目の既定値を補う
+func migrateV0(settings map[string]any, defaults map[string]any) {
+	for key := range defaults {
+		fillDefault(settings, defaults, key)
+	}
+}
+
+// migrateV1 Program/Cut/Autoに追加した誤操作防止モード(armMode/armTime)の既定値を補う
+func migrateV1(settings map[string]any, defaults map[string]any) {
+	fillDefault(settings, defaults, "armMode")
+	fillDefault(settings, defaults, "armTime")
+}
+
+// migrateV2 Lockに追加した切り替えるモード(lockMode)の既定値を補う
+func migrateV2(settings map[string]any, defaults map[string]any) {
+	fillDefault(settings, defaults, "lockMode")
+}
+
+// fillDefault アクションの既定値にkeyがあり、設定が未設定の場合に既定値を補う
+func fillDefault(settings map[string]any, defaults map[string]any, key string) {
+	value, ok := defaults[key]
+	if !ok {
+		return
+	}
+	if v, ok := settings[key]; !ok || v == nil || v == "" {
+		settings[key] = value
+	}
+}
+
+var (
+	// tallySettingsDefaults Previewの既定値
+	tallySettingsDefaults = map[string]any{
+		"switcher":  "",
+		"ip":        "",
+		"input":     1,
+		"meIndex":   0,
+		"tallyMode": 1,
+	}
+	// inputSettingsDefaults Inputの既定値
+	inputSettingsDefaults = map[string]any{
+		"switcher":  "",
+		"ip":        "",
+		"input":     1,
+		"meIndex":   0,
+		"pressMode": string(inputPressPreview),
+	}
+	// programSettingsDefaults Programの既定値
+	programSettingsDefaults = map[string]any{
+		"switcher":  "",
+		"ip":        "",
+		"input":     1,
+		"meIndex":   0,
+		"tallyMode": 1,
+		"armMode":   string(armModeOff),
+		"armTime":   "",
+	}
+	// transitionSettingsDefaults Cut/Auto/FTBの既定値
+	transitionSettingsDefaults = map[string]any{
+		"switcher": "",
+		"ip":       "",
+		"armMode":  string(armModeOff),
+		"armTime":  "",
+	}
+	// diagnosticsSettingsDefaults Diagnosticsの既定値
+	diagnosticsSettingsDefaults = map[string]any{
+		"switcher": "",
+		"ip":       "",
+	}
+	// lockSettingsDefaults Lockの既定値
+	lockSettingsDefaults = map[string]any{
+		"lockMode": string(setting.LockModeLocked),
+	}
+)
+
+// migrateSettings 設定を現在のバージョンまで移行する。移行した場合はtrueを返す
+func migrateSettings(raw json.RawMessage, defaults map[string]any) (json.RawMessage, bool, error) {
+	settings := map[string]any{}
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &settings); err != nil {
+			return nil, false, xerrors.Errorf("設定のアンマーシャルに失敗: %w", err)
+		}
+	}
+
+	// Property Inspectorからは文字列として保存される
+	version := 0
+	switch v := settings["version"].(type) {
+	case float64:
+		version = int(v)
+	case string:
+		version, _ = strconv.Atoi(v)
+	}
+	if version >= settingsVersion {
+		return raw, false, nil
+	}
+
+	for ; version < settingsVersion; version++ {
+		migrations[version](settings, defaults)
+	}
+	settings["version"] = settingsVersion
+
+	migrated, err := json.Marshal(settings)
+	if err != nil {
+		return nil, false, xerrors.Errorf("設定のマーシャルに失敗: %w", err)
+	}
+	return migrated, true, nil
+}
+
+// decodeSettings 設定を移行してからTにデコードする
+// writeBackがtrueの場合、移行した設定をsetSettingsでStream Deckに書き戻す
+func decodeSettings[T any](ctx context.Context, a *App, contextID string, raw json.RawMessage, defaults map[string]any, writeBack bool) (*T, error) {
+	migrated, changed, err := migrateSettings(raw, defaults)
+	if err != nil {
+		return nil, xerrors.Errorf("設定の移行に失敗: %w", err)
+	}
+	if changed && writeBack {
+		a.logger.Info(ctx, "設定をバージョン %d に移行しました contextID:%s", settingsVersion, contextID)
+		if err := a.sd.SetSettings(sdcontext.WithContext(ctx, contextID), migrated); err != nil {
+			a.logger.Error(ctx, "移行した設定の書き戻しに失敗: %v", err)
+			a.sdSendFailed(streamdeck.SetSettings)
+		}
+	}
+
+	settings := new(T)
+	if err := json.Unmarshal(migrated, settings); err != nil {
+		return nil, xerrors.Errorf("設定のアンマーシャルに失敗: %w", err)
+	}
+	return settings, nil
+}
+
+// showInvalidSettings 不正な設定をログに記録し、ボタンにアラートを表示する
+func (a *App) showInvalidSettings(ctx context.Context, contextID string, err error) {
+	a.logger.Error(ctx, "設定が不正です contextID:%s: %v", contextID, err)
+	a.showAlert(ctx, contextID)
+}
@@ -0,0 +1,85 @@
+package stdatem
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		defaults map[string]any
+		want     map[string]any
+		changed  bool
+	}{
+		{
+			name:     "未設定",
+			raw:      `null`,
+			defaults: transitionSettingsDefaults,
+			want:     map[string]any{"version": float64(3), "switcher": "", "ip": "", "armMode": "", "armTime": ""},
+			changed:  true,
+		},
+		{
+			name:     "バージョン管理導入前",
+			raw:      `{"ip":"10.0.0.1","input":"5"}`,
+			defaults: programSettingsDefaults,
+			want: map[string]any{
+				"version": float64(3), "switcher": "", "ip": "10.0.0.1", "input": "5", "meIndex": float64(0),
+				"tallyMode": float64(1), "armMode": "", "armTime": "",
+			},
+			changed: true,
+		},
+		{
+			name:     "v1は誤操作防止モードのみ補う",
+			raw:      `{"version":"1","ip":"10.0.0.1"}`,
+			defaults: transitionSettingsDefaults,
+			want:     map[string]any{"version": float64(3), "ip": "10.0.0.1", "armMode": "", "armTime": ""},
+			changed:  true,
+		},
+		{
+			name:     "v2のLockにlockModeを補う",
+			raw:      `{"version":2}`,
+			defaults: lockSettingsDefaults,
+			want:     map[string]any{"version": float64(3), "lockMode": "locked"},
+			changed:  true,
+		},
+		{
+			name:     "既定値にない項目は追加しない",
+			raw:      `{"version":2,"ip":"10.0.0.1"}`,
+			defaults: transitionSettingsDefaults,
+			want:     map[string]any{"version": float64(3), "ip": "10.0.0.1"},
+			changed:  true,
+		},
+		{
+			name:     "最新",
+			raw:      `{"version":3,"ip":"10.0.0.1"}`,
+			defaults: transitionSettingsDefaults,
+			want:     map[string]any{"version": float64(3), "ip": "10.0.0.1"},
+			changed:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrated, changed, err := migrateSettings(json.RawMessage(tt.raw), tt.defaults)
+			if err != nil {
+				t.Fatalf("migrateSettings: %v", err)
+			}
+			if changed != tt.changed {
+				t.Errorf("changed = %v, want %v", changed, tt.changed)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(migrated, &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("migrated = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := migrateSettings(json.RawMessage(`[1]`), transitionSettingsDefaults); err == nil {
+		t.Error("不正な設定でエラーになりません")
+	}
+}
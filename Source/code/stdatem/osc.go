@@ -0,0 +1,106 @@
+package stdatem
+
+import (
+	"context"
+	"slices"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/asrun"
+	"github.com/FlowingSPDG/std-atem/Source/code/osc"
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+)
+
+// oscServiceName OSCのサービス名
+const oscServiceName = "osc"
+
+// oscService 起動中のOSCサーバー
+type oscService struct {
+	settings   setting.OSCSettings
+	server     osc.Server
+	controller *switcherController
+	unwatch    []func() // フィードバックの購読解除
+}
+
+// applyOSC グローバル設定に従ってOSCサーバーを起動・停止し、フィードバックするスイッチャーを紐付け直す
+func (a *App) applyOSC(ctx context.Context) {
+	settings := a.globalSettings.Load().OSCSettings()
+
+	if a.osc != nil && !sameOSCServer(a.osc.settings, settings) {
+		a.stopOSC(ctx)
+	}
+	if !settings.Enabled {
+		return
+	}
+
+	if a.osc == nil {
+		controller := a.newSwitcherController(oscServiceName, asrun.OriginOSC)
+		server, err := osc.NewServer(osc.Config{
+			Address:    settings.Address,
+			Targets:    settings.Targets,
+			Controller: controller,
+			Logger:     a.logger.With("service", "osc"),
+		})
+		if err != nil {
+			a.logger.Error(ctx, "OSCサーバーの起動に失敗: %v", err)
+			return
+		}
+		a.logger.Info(ctx, "OSCサーバーを開始 %s フィードバック:%v", server.Addr(), settings.Targets)
+		go func() {
+			if err := server.Run(ctx); err != nil {
+				a.logger.Error(ctx, "OSCサーバーが終了しました: %v", err)
+			}
+		}()
+		a.osc = &oscService{server: server, controller: controller}
+	}
+	a.osc.settings = settings
+	a.watchOSCFeedback(ctx)
+}
+
+// watchOSCFeedback フィードバックするスイッチャーを現在のグローバル設定で購読し直す
+func (a *App) watchOSCFeedback(ctx context.Context) {
+	for _, unwatch := range a.osc.unwatch {
+		unwatch()
+	}
+	a.osc.unwatch = nil
+	if len(a.osc.settings.Targets) == 0 {
+		return
+	}
+
+	names := a.osc.settings.Feedback
+	if gs := a.globalSettings.Load(); len(names) == 0 && gs != nil {
+		for _, s := range gs.Switchers {
+			names = append(names, s.Name)
+		}
+	}
+	for _, name := range names {
+		instance, ok := a.acquireSwitcher(ctx, oscServiceName+"/"+name, name)
+		if !ok {
+			a.logger.Warn(ctx, "OSC フィードバック スイッチャー %q の接続先が見つかりません", name)
+			continue
+		}
+		a.osc.controller.switchers.Store(name, struct{}{})
+		a.osc.unwatch = append(a.osc.unwatch, a.osc.server.Watch(name, instance.State))
+	}
+}
+
+// stopOSC OSCサーバーを停止する
+func (a *App) stopOSC(ctx context.Context) {
+	if a.osc == nil {
+		return
+	}
+	for _, unwatch := range a.osc.unwatch {
+		unwatch()
+	}
+	if err := a.osc.server.Close(); err != nil {
+		a.logger.Warn(ctx, "OSCサーバーの停止に失敗: %v", err)
+	}
+	a.osc.controller.release(ctx)
+	a.osc = nil
+	a.logger.Info(ctx, "OSCサーバーを停止")
+}
+
+// sameOSCServer 再起動せずに設定を反映できるか。フィードバックするスイッチャー以外が一致する場合にtrueを返す
+func sameOSCServer(x, y setting.OSCSettings) bool {
+	return x.Enabled == y.Enabled &&
+		x.Address == y.Address &&
+		slices.Equal(x.Targets, y.Targets)
+}
@@ -2,100 +2,38 @@ package stdatem
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 
-	"github.com/FlowingSPDG/streamdeck"
-	"golang.org/x/xerrors"
+	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
 )
 
-// PRVWillAppearHandler ATEM PRVを設定
-func (a *App) PRVWillAppearHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
-	var payload streamdeck.WillAppearPayload[*PreviewPropertyInspector]
-	if err := json.Unmarshal(event.Payload, &payload); err != nil {
-		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
-		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
+// previewActionDef ATEM PRVを設定するアクション
+func (a *App) previewActionDef() actionDef[PreviewPropertyInspector, *previewPropertyInspector] {
+	return actionDef[PreviewPropertyInspector, *previewPropertyInspector]{
+		uuid:     setPreviewAction,
+		name:     "PRV",
+		defaults: tallySettingsDefaults,
+		parse:    (*PreviewPropertyInspector).Parse,
+		keyDown:  a.previewKeyDown,
+		control:  true,
+		render:   a.renderPreviewTally,
+		events: func(p *previewPropertyInspector) []state.EventType {
+			return tallyEvents(p.TallyMode, tallyBusPreview)
+		},
+		expect: func(p *previewPropertyInspector, st state.Switcher) ackMatcher {
+			return expectPreview(st, p.MeIndex, uint16(p.Input))
+		},
 	}
-	parsed, err := payload.Settings.Parse()
-	if err != nil {
-		a.logger.Error(ctx, fmt.Sprintf("payloadのパースに失敗: %v", err))
-		return xerrors.Errorf("payloadのパースに失敗: %w", err)
-	}
-
-	msg := fmt.Sprintf("PRV %#v でWillAppear", parsed)
-	a.logger.Debug(ctx, msg)
-
-	a.previewSettingStore.Store(event.Context, parsed)
-
-	// 新しいインスタンスを初期化
-	if err := a.addATEMHost(ctx, setPreviewAction, event.Context, parsed.IP, false); err != nil {
-		return xerrors.Errorf("ATEMホストの追加に失敗: %w", err)
-	}
-
-	return nil
 }
 
-// PRVWillDisappearHandler プレビューのボタン非表示を処理
-func (a *App) PRVWillDisappearHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
-	var payload streamdeck.WillDisappearPayload[PreviewPropertyInspector]
-	if err := json.Unmarshal(event.Payload, &payload); err != nil {
-		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
-		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
-	}
-	a.handleDisappear(ctx, event.Context)
-	return nil
-}
-
-// PRVKeyDownHandler ATEM PRVを設定
-func (a *App) PRVKeyDownHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
-	var payload streamdeck.KeyDownPayload[PreviewPropertyInspector]
-	if err := json.Unmarshal(event.Payload, &payload); err != nil {
-		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
-		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
-	}
-
-	parsed, err := payload.Settings.Parse()
-	if err != nil {
-		a.logger.Error(ctx, fmt.Sprintf("payloadのパースに失敗: %v", err))
-		return xerrors.Errorf("payloadのパースに失敗: %w", err)
-	}
-
-	msg := fmt.Sprintf("PRV %v でKeyDown", parsed)
-	a.logger.Debug(ctx, msg)
-
-	instance, ok := a.connectionManager.SolveATEMByContext(ctx, event.Context)
-	if !ok {
-		a.logger.Error(ctx, "PRVKeyDownHandler ATEMが見つかりません")
-		return xerrors.New("PRVKeyDownHandler ATEMが見つかりません")
-	}
-
-	a.logger.Debug(ctx, "PRVKeyDownHandler input:%d meIndex:%d", parsed.Input, parsed.MeIndex)
-
-	instance.Client.SetPreviewInput(parsed.Input, parsed.MeIndex)
-	a.logger.Debug(ctx, "PRVKeyDownHandler 完了")
-	return nil
+// previewKeyDown ATEM PRVを設定
+func (a *App) previewKeyDown(ctx context.Context, instance *connectionmanager.ATEMInstance, p *previewPropertyInspector) error {
+	a.logger.Debug(ctx, "previewKeyDown input:%d meIndex:%d", p.Input, p.MeIndex)
+	return instance.Client.SetPreviewInput(p.Input, p.MeIndex)
 }
 
-// PRVDidReceiveSettingsHandler PRVの設定を受け取る
-func (a *App) PRVDidReceiveSettingsHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
-	var payload streamdeck.DidReceiveSettingsPayload[PreviewPropertyInspector]
-	if err := json.Unmarshal(event.Payload, &payload); err != nil {
-		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
-		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
-	}
-
-	parsed, err := payload.Settings.Parse()
-	if err != nil {
-		a.logger.Error(ctx, fmt.Sprintf("payloadのパースに失敗: %v", err))
-		return xerrors.Errorf("payloadのパースに失敗: %w", err)
-	}
-
-	// 新しいインスタンスを初期化
-	if err := a.addATEMHost(ctx, setPreviewAction, event.Context, parsed.IP, true); err != nil {
-		return xerrors.Errorf("ATEMホストの追加に失敗: %w", err)
-	}
-
-	a.previewSettingStore.Store(event.Context, parsed)
-
-	return nil
+// renderPreviewTally 状態キャッシュからPreviewのタリーを描画する
+func (a *App) renderPreviewTally(ctx context.Context, contextID string, p *previewPropertyInspector, st state.Switcher) {
+	a.logger.Debug(ctx, "renderPreviewTally setting:%v", p)
+	a.renderTally(ctx, contextID, p.TallyMode, tallyBusPreview, uint16(p.Input), p.MeIndex, st)
 }
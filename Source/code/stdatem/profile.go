@@ -0,0 +1,184 @@
+package stdatem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/profile"
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+	"github.com/FlowingSPDG/streamdeck"
+	sdcontext "github.com/FlowingSPDG/streamdeck/context"
+	"golang.org/x/xerrors"
+)
+
+// profileDir プロファイルの既定の書き出し先。プラグインのフォルダからの相対パス
+const profileDir = "profiles"
+
+const (
+	// profileCommandExport プロファイルを書き出す
+	profileCommandExport = "exportProfile"
+	// profileCommandImport プロファイルを読み込む
+	profileCommandImport = "importProfile"
+)
+
+// profileCommand Property Inspectorから送信されるプロファイル操作
+type profileCommand struct {
+	Command string            `json:"command"`
+	Path    string            `json:"path"`
+	Remap   map[string]string `json:"remap"`
+}
+
+// profileResult Property Inspectorに返すプロファイル操作の結果
+type profileResult struct {
+	Event   string `json:"event"`
+	Path    string `json:"path,omitempty"`
+	Buttons int    `json:"buttons"` // 書き出した、または適用したボタンの数
+	Error   string `json:"error,omitempty"`
+}
+
+// ProfileSendToPluginHandler Property Inspectorからのプロファイル操作を処理する
+func (a *App) ProfileSendToPluginHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
+	var cmd profileCommand
+	if err := json.Unmarshal(event.Payload, &cmd); err != nil {
+		a.logger.Error(ctx, fmt.Sprintf("payloadのアンマーシャルに失敗: %v", err))
+		return xerrors.Errorf("payloadのアンマーシャルに失敗: %w", err)
+	}
+
+	var result profileResult
+	var err error
+	switch cmd.Command {
+	case profileCommandExport:
+		result.Event = "profileExported"
+		result.Path, err = a.exportProfile(ctx, cmd.Path)
+		result.Buttons = a.buttonCount()
+	case profileCommandImport:
+		result.Event = "profileImported"
+		result.Path = cmd.Path
+		result.Buttons, err = a.importProfile(ctx, cmd.Path, cmd.Remap)
+	default:
+		// プロファイル以外のメッセージは無視する
+		return nil
+	}
+
+	sdctx := sdcontext.WithContext(ctx, event.Context)
+	if err != nil {
+		a.logger.Error(ctx, "プロファイルの%sに失敗: %v", cmd.Command, err)
+		result.Error = err.Error()
+		a.sd.ShowAlert(sdctx)
+	} else {
+		a.sd.ShowOk(sdctx)
+	}
+	if err := a.sd.SendToPropertyInspector(sdctx, result); err != nil {
+		a.logger.Error(ctx, "Property Inspectorへの送信に失敗: %v", err)
+		a.sdSendFailed(streamdeck.SendToPropertyInspector)
+	}
+	return nil
+}
+
+func (a *App) buttonCount() int {
+	n := 0
+	a.registry.Range(func(setting.Entry) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// exportProfile 表示中のボタンとグローバル設定をpathに書き出す。pathが空の場合はprofiles以下に書き出す
+func (a *App) exportProfile(ctx context.Context, path string) (string, error) {
+	now := time.Now()
+	path = a.pluginPath(path, filepath.Join(profileDir, fmt.Sprintf("profile-%s.json", now.Format("20060102-150405"))))
+
+	p := &profile.Profile{
+		Version:    profile.Version,
+		ExportedAt: now,
+	}
+	if gs := a.globalSettings.Load(); gs != nil {
+		p.Switchers = gs.Switchers
+	}
+	a.registry.Range(func(e setting.Entry) bool {
+		p.Buttons = append(p.Buttons, profile.Button{
+			Action:      e.Action,
+			Device:      e.Device,
+			Coordinates: e.Coordinates,
+			Settings:    e.RawSettings,
+		})
+		return true
+	})
+
+	if err := profile.Save(path, p); err != nil {
+		return "", xerrors.Errorf("プロファイルの書き出しに失敗: %w", err)
+	}
+	a.logger.Info(ctx, "プロファイルを %s に書き出しました (ボタン %d 個)", path, len(p.Buttons))
+	return path, nil
+}
+
+// importProfile pathのプロファイルをIPを置き換えて読み込み、適用したボタンの数を返す
+// ボタンは同じアクションと位置で表示中のものにのみ適用される
+func (a *App) importProfile(ctx context.Context, path string, remap map[string]string) (int, error) {
+	path = a.pluginPath(path, "")
+	loaded, err := profile.Load(path)
+	if err != nil {
+		return 0, xerrors.Errorf("プロファイルの読み込みに失敗: %w", err)
+	}
+	p, err := loaded.Remap(remap)
+	if err != nil {
+		return 0, xerrors.Errorf("IPの置き換えに失敗: %w", err)
+	}
+
+	// スイッチャー以外のグローバル設定は現在の値を引き継ぐ
+	if err := a.updateGlobalSettings(ctx, func(gs *setting.GlobalSettings) {
+		gs.Switchers = p.Switchers
+	}); err != nil {
+		return 0, err
+	}
+	a.applyLogLevel(ctx)
+	a.rebindAll(ctx)
+	a.applyServices(ctx)
+
+	applied := 0
+	a.registry.Range(func(e setting.Entry) bool {
+		b, ok := p.FindButton(e.Action, e.Device, e.Coordinates)
+		if !ok {
+			return true
+		}
+		if err := a.applySettings(ctx, e, b.Settings); err != nil {
+			a.logger.Error(ctx, "ボタン設定の適用に失敗 contextID:%s: %v", e.Context, err)
+			return true
+		}
+		applied++
+		return true
+	})
+	a.logger.Info(ctx, "プロファイル %s を読み込みました (ボタン %d/%d 個)", path, applied, len(p.Buttons))
+	return applied, nil
+}
+
+// applySettings ボタンの設定を保存し、DidReceiveSettingsと同様に反映する
+func (a *App) applySettings(ctx context.Context, e setting.Entry, settings json.RawMessage) error {
+	if err := a.sd.SetSettings(sdcontext.WithContext(ctx, e.Context), settings); err != nil {
+		a.sdSendFailed(streamdeck.SetSettings)
+		return xerrors.Errorf("設定の保存に失敗: %w", err)
+	}
+
+	act, ok := a.actions[e.Action]
+	if !ok {
+		return xerrors.Errorf("アクション %s は設定の反映に対応していません", e.Action)
+	}
+	payload, err := json.Marshal(streamdeck.DidReceiveSettingsPayload[json.RawMessage]{
+		Settings:    settings,
+		Coordinates: streamdeck.Coordinates{Column: e.Coordinates.Column, Row: e.Coordinates.Row},
+	})
+	if err != nil {
+		return xerrors.Errorf("payloadのマーシャルに失敗: %w", err)
+	}
+	return act.didReceiveSettings(ctx, a.sd, streamdeck.Event{
+		Action:  e.Action,
+		Event:   streamdeck.DidReceiveSettings,
+		Context: e.Context,
+		Device:  e.Device,
+		Payload: payload,
+	})
+}
@@ -0,0 +1,77 @@
+package stdatem
+
+import (
+	"context"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+	"github.com/FlowingSPDG/streamdeck"
+)
+
+// serviceContextPrefix ボタン以外の機能がATEMとの接続を保持するための疑似Contextの接頭辞
+const serviceContextPrefix = "service:"
+
+// acquireSwitcher サービスnameをスイッチャーに紐付け、共有のATEMInstanceを返す
+// switcherは名前付きスイッチャーの名前か、ATEMのアドレスを受け付ける
+func (a *App) acquireSwitcher(ctx context.Context, name, switcher string) (*connectionmanager.ATEMInstance, bool) {
+	contextID := serviceContextPrefix + name
+	a.hostsMu.Lock()
+	defer a.hostsMu.Unlock()
+
+	host, ok := a.resolveHost(switcher, switcher)
+	if !ok {
+		a.connectionManager.DeleteATEMByContext(ctx, contextID)
+		return nil, false
+	}
+
+	if instance, ok := a.connectionManager.SolveATEMByContext(ctx, contextID); ok {
+		if instance.Client.IP() == host {
+			return instance, true
+		}
+		a.connectionManager.DeleteATEMByContext(ctx, contextID)
+	}
+
+	if err := a.addATEMHostLocked(ctx, contextID, contextID, host, false); err != nil {
+		a.logger.Error(ctx, "%s ATEMホストの追加に失敗: %v", name, err)
+		return nil, false
+	}
+	return a.connectionManager.SolveATEMByContext(ctx, contextID)
+}
+
+// releaseSwitcher サービスnameとスイッチャーの紐付けを解除する
+func (a *App) releaseSwitcher(ctx context.Context, name string) {
+	a.connectionManager.DeleteATEMByContext(ctx, serviceContextPrefix+name)
+}
+
+// applyServices グローバル設定に従ってボタン以外の機能を起動・停止する
+func (a *App) applyServices(ctx context.Context) {
+	a.servicesMu.Lock()
+	defer a.servicesMu.Unlock()
+
+	a.applyVMix(ctx)
+	a.applyTSL(ctx)
+	a.applyAPI(ctx)
+	a.applyOSC(ctx)
+	a.applyAsRun(ctx)
+	a.applyMetrics(ctx)
+	a.applyRehearsal(ctx)
+}
+
+// stopServices 起動中の全てのサービスを停止する
+func (a *App) stopServices(ctx context.Context) {
+	a.servicesMu.Lock()
+	defer a.servicesMu.Unlock()
+
+	a.stopVMix(ctx)
+	a.stopTSL(ctx)
+	a.stopAPI(ctx)
+	a.stopOSC(ctx)
+	a.stopAsRun(ctx)
+	a.stopMetrics(ctx)
+	a.stopRehearsal(ctx)
+}
+
+// DeviceDidConnectHandler デバイスの接続時にグローバル設定を要求し、ボタンが無くてもサービスを起動できるようにする
+func (a *App) DeviceDidConnectHandler(ctx context.Context, client *streamdeck.Client, event streamdeck.Event) error {
+	a.requestGlobalSettings(ctx)
+	return nil
+}
@@ -2,50 +2,84 @@ package stdatem
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/FlowingSPDG/go-atem"
+	"github.com/FlowingSPDG/std-atem/Source/code/atemclient"
 	"github.com/FlowingSPDG/std-atem/Source/code/connectionmanager"
+	"github.com/FlowingSPDG/std-atem/Source/code/cutlist"
+	"github.com/FlowingSPDG/std-atem/Source/code/diag"
 	"github.com/FlowingSPDG/std-atem/Source/code/logger"
 	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
 	"github.com/FlowingSPDG/streamdeck"
-	sdcontext "github.com/FlowingSPDG/streamdeck/context"
-	"github.com/puzpuzpuz/xsync"
-	"github.com/samber/lo"
+	"github.com/puzpuzpuz/xsync/v3"
 	"golang.org/x/xerrors"
 )
 
 // App メインエンジン
 type App struct {
-	connectionManager   *connectionmanager.ConnectionManager // コンテキスト（ボタン）ごとの設定
-	logger              logger.Logger                        // ログ
-	sd                  *streamdeck.Client                   // StreamDeckクライアント
-	previewSettingStore setting.SettingStore[*previewPropertyInspector]
-	programSettingStore setting.SettingStore[*programPropertyInspector]
-	refCounts           *xsync.MapOf[string, int]
-	activeClients       *xsync.MapOf[string, *connectionmanager.ATEMInstance]
+	connectionManager  *connectionmanager.ConnectionManager // コンテキスト（ボタン）ごとの設定
+	clientFactory      atemclient.Factory                   // ATEMクライアントの生成
+	logger             logger.Logger                        // ログ
+	sd                 *streamdeck.Client                   // StreamDeckクライアント
+	pluginUUID         string                               // グローバル設定の読み書きに利用するプラグインUUID
+	globalSettings     atomic.Pointer[setting.GlobalSettings]
+	globalSettingsOnce sync.Once
+	actions            map[string]registeredAction // アクションUUID: アクション。setupSDでのみ書き込む
+	registry           setting.Registry            // context: アクションの設定と接続先
+	refCounts          *xsync.MapOf[string, int]
+	activeClients      *xsync.MapOf[string, *connectionmanager.ATEMInstance]
+	hostsMu            sync.Mutex // ATEMInstanceの取得・作成を直列化し、同じホストへの重複接続を防ぐ
+
+	servicesMu    sync.Mutex                   // 以下のサービスを保護する
+	vmix          *vmixService                 // vMix互換タリーサーバー。無効の場合はnil
+	tsl           *tslService                  // TSL UMD送信。無効の場合はnil
+	api           *apiService                  // ローカルHTTP API。無効の場合はnil
+	osc           *oscService                  // OSCサーバー。無効の場合はnil
+	asrun         atomic.Pointer[asrunService] // As-Runログ。状態変化の配送から参照するためatomicで保持する
+	metricsServer *metricsService              // メトリクスのHTTPサーバー。無効の場合はnil
+	rehearsal     *rehearsalService            // リハーサル用のスイッチャー。リハーサル中以外はnil
+
+	cutLists  *xsync.MapOf[string, cutlist.History] // ATEMホスト: PGMの履歴。再接続しても引き継ぐ
+	tracker   diag.Tracker                          // ATEMホストごとの接続の履歴
+	pluginDir string                                // プラグインのフォルダ。相対パスで指定された出力先の基準
+	logDir    string                                // ログの出力先。診断データに含める
+	metrics   *appMetrics                           // メトリクス。公開していない間も集計する
+	acks      *xsync.MapOf[string, *pendingAck]     // context: 反映を待っている操作
+	arms      *xsync.MapOf[string, *armState]       // context: 誤操作防止モードで確定を待っているボタン
+	lockMode  atomic.Value                          // 表示に反映済みのパネルロックのモード(setting.LockMode)
 }
 
 // NewApp Appメインエンジンを初期化する
-func NewApp(ctx context.Context, logger logger.Logger, sd *streamdeck.Client) (*App, error) {
+// pluginDirは相対パスで指定された出力先の基準となるフォルダ
+// logDirはロガーの出力先で、診断データにログを含めるために利用する
+func NewApp(ctx context.Context, logger logger.Logger, sd *streamdeck.Client, pluginUUID string, pluginDir string, logDir string, clientFactory atemclient.Factory) (*App, error) {
 	app := &App{
-		connectionManager:   connectionmanager.NewConnectionManager(logger),
-		logger:              logger,
-		sd:                  sd,
-		previewSettingStore: setting.NewSettingStore[*previewPropertyInspector](),
-		programSettingStore: setting.NewSettingStore[*programPropertyInspector](),
-		refCounts:           xsync.NewMapOf[int](),
-		activeClients:       xsync.NewMapOf[*connectionmanager.ATEMInstance](),
+		connectionManager: connectionmanager.NewConnectionManager(logger),
+		clientFactory:     clientFactory,
+		logger:            logger,
+		sd:                sd,
+		pluginUUID:        pluginUUID,
+		actions:           map[string]registeredAction{},
+		registry:          setting.NewRegistry(),
+		refCounts:         xsync.NewMapOf[string, int](),
+		activeClients:     xsync.NewMapOf[string, *connectionmanager.ATEMInstance](),
+		cutLists:          xsync.NewMapOf[string, cutlist.History](),
+		tracker:           diag.NewTracker(),
+		pluginDir:         pluginDir,
+		logDir:            logDir,
+		acks:              xsync.NewMapOf[string, *pendingAck](),
+		arms:              xsync.NewMapOf[string, *armState](),
 	}
+	app.metrics = newAppMetrics(app)
 
 	// SDのセットアップ
-	params, err := streamdeck.ParseRegistrationParams(os.Args)
-	if err != nil {
-		return nil, xerrors.Errorf("registration paramsの解析に失敗: %w", err)
-	}
-	app.sd = streamdeck.NewClient(ctx, params)
 	app.setupSD()
 
 	return app, nil
@@ -53,110 +87,83 @@ func NewApp(ctx context.Context, logger logger.Logger, sd *streamdeck.Client) (*
 
 // addATEMHost 新しいATEMホストを追加し、接続をセットアップする
 func (a *App) addATEMHost(ctx context.Context, action string, contextID string, ip string, debug bool) error {
+	a.hostsMu.Lock()
+	defer a.hostsMu.Unlock()
+	return a.addATEMHostLocked(ctx, action, contextID, ip, debug)
+}
+
+// addATEMHostLocked hostsMuを保持した状態でATEMホストを追加する
+func (a *App) addATEMHostLocked(ctx context.Context, action string, contextID string, ip string, debug bool) error {
 	msg := fmt.Sprintf("ATEMホスト %s を追加中...", ip)
 	a.logger.Debug(ctx, msg)
 
 	if instance, ok := a.connectionManager.SolveATEMByIP(ctx, ip); ok {
 		a.logger.Debug(ctx, "ATEMホスト %s は既に存在します", ip)
-		contexts, ok := a.connectionManager.SolveContextsByIP(ctx, ip)
-		if !ok {
-			a.logger.Error(ctx, "ATEMが見つかりません")
-			return xerrors.New("ATEMが見つかりません")
-		} else {
-			contexts = append(contexts, connectionmanager.ActionAndContext{Action: action, Context: contextID})
-			a.connectionManager.Store(ctx, action, ip, contextID, instance)
-			return nil
-		}
+		a.connectionManager.Store(ctx, action, ip, contextID, instance)
+		return nil
 	}
 
 	instance := &connectionmanager.ATEMInstance{
-		Client:      atem.Create(ip, debug),
+		Client:      a.clientFactory(ip, debug),
+		State:       state.NewSwitcher(),
 		ReconnectCh: make(chan struct{}, 1),
 	}
 
 	a.connectionManager.Store(ctx, action, ip, contextID, instance)
 
-	instance.Client.On("connected", func() {
+	// ATEMクライアントのイベントを状態キャッシュに反映する
+	instance.Client.On(atemclient.EventConnected, func() {
 		a.logger.Debug(ctx, fmt.Sprintf("ATEM %s に接続しました", ip))
+		a.tracker.Connected(ip)
+		instance.State.SetConnected(true)
 	})
 
-	instance.Client.On("PrvI.change", func() {
+	instance.Client.On(atemclient.EventPreviewChanged, func() {
 		a.logger.Debug(ctx, "PrvI.change")
-
-		// 紐づいたContextを取得
-		actions, ok := a.connectionManager.SolveContextsByIP(ctx, ip)
-		if !ok {
-			a.logger.Error(ctx, "PrvI.change ATEMが見つかりません")
-			return
-		}
-		a.logger.Debug(ctx, "PrvI.change actions:%v", actions)
-		actions = lo.Filter(actions, func(action connectionmanager.ActionAndContext, _ int) bool {
-			return action.Action == setPreviewAction
-		})
-		a.logger.Debug(ctx, "PrvI.change contexts:%v", actions)
-
-		for _, action := range actions {
-			previewSetting, ok := a.previewSettingStore.Load(action.Context)
+		for meIndex := uint8(0); ; meIndex++ {
+			input, ok := instance.Client.PreviewInput(meIndex)
 			if !ok {
-				a.logger.Error(ctx, "previewSettingが見つかりません")
-				return
-			}
-
-			// TODO: M/Eが違う場合は無視する
-			a.logger.Debug(ctx, "PrvI.change input:%d meIndex:%d PreviewInput:%v", previewSetting.Input, previewSetting.MeIndex, instance.Client.PreviewInput)
-			isActive := uint8(previewSetting.Input) == uint8(instance.Client.PreviewInput.Index)
-			a.logger.Debug(ctx, "PrvI.change setting:%v actual:%d isActive:%t", previewSetting, instance.Client.PreviewInput.Index, isActive)
-
-			// タリーを反映
-			sdctx := sdcontext.WithContext(ctx, action.Context)
-			if isActive {
-				a.sd.SetImage(sdctx, tallyPreview, streamdeck.HardwareAndSoftware)
-			} else {
-				a.sd.SetImage(sdctx, tallyInactive, streamdeck.HardwareAndSoftware)
+				break
 			}
+			instance.State.SetPreview(meIndex, input)
 		}
 	})
 
-	instance.Client.On("PrgI.change", func() {
+	instance.Client.On(atemclient.EventProgramChanged, func() {
 		a.logger.Debug(ctx, "PrgI.change")
-
-		// 紐づいたContextを取得
-		actions, ok := a.connectionManager.SolveContextsByIP(ctx, ip)
-		if !ok {
-			a.logger.Error(ctx, "PrgI.change ATEMが見つかりません")
-			return
-		}
-		a.logger.Debug(ctx, "PrgI.change actions:%v", actions)
-		actions = lo.Filter(actions, func(action connectionmanager.ActionAndContext, _ int) bool {
-			return action.Action == setProgramAction
-		})
-		a.logger.Debug(ctx, "PrgI.change contexts:%v", actions)
-
-		for _, action := range actions {
-			programSetting, ok := a.programSettingStore.Load(action.Context)
+		for meIndex := uint8(0); ; meIndex++ {
+			input, ok := instance.Client.ProgramInput(meIndex)
 			if !ok {
-				a.logger.Error(ctx, "PrgI.change programSettingが見つかりません")
-				return
-			}
-
-			// TODO: M/Eが違う場合は無視する
-			a.logger.Debug(ctx, "PrgI.change input:%d meIndex:%d PreviewInput:%v", programSetting.Input, programSetting.MeIndex, instance.Client.ProgramInput.Index)
-			isActive := uint8(programSetting.Input) == uint8(instance.Client.ProgramInput.Index)
-			a.logger.Debug(ctx, "PrgI.change setting:%v actual:%d isActive:%t", programSetting, instance.Client.ProgramInput.Index, isActive)
-
-			// タリーを反映
-			sdctx := sdcontext.WithContext(ctx, action.Context)
-			if isActive {
-				a.sd.SetImage(sdctx, tallyProgram, streamdeck.HardwareAndSoftware)
-			} else {
-				a.sd.SetImage(sdctx, tallyInactive, streamdeck.HardwareAndSoftware)
+				break
 			}
+			instance.State.SetProgram(meIndex, input)
+		}
+	})
 
+	instance.Client.On(atemclient.EventInputChanged, func() {
+		for _, in := range instance.Client.Inputs() {
+			instance.State.SetInputName(in.Input, in.LongName, in.ShortName)
 		}
 	})
 
-	instance.Client.On("closed", func() {
+	// 状態の変化を購読し、紐づいたContextのタリーを更新する
+	instance.State.Subscribe(func(ev state.Event) {
+		a.handleStateEvent(ctx, ip, instance, ev)
+	}, state.EventPreview, state.EventProgram)
+	instance.State.Subscribe(func(ev state.Event) {
+		a.recordAsRun(ctx, instance, ev)
+	}, asrunEvents...)
+	instance.State.Subscribe(func(ev state.Event) {
+		a.recordCut(instance, ev)
+	}, state.EventProgram)
+	instance.State.Subscribe(func(ev state.Event) {
+		a.resolveAcks(ctx, ip, ev)
+	}, ackEvents...)
+
+	instance.Client.On(atemclient.EventClosed, func() {
 		a.logger.Debug(ctx, fmt.Sprintf("ATEM %s への接続を閉じました", ip))
+		a.tracker.Disconnected(ip)
+		instance.State.SetConnected(false)
 		if instance, ok := a.connectionManager.SolveATEMByIP(ctx, ip); ok {
 
 			// 再接続をトリガー
@@ -171,44 +178,72 @@ func (a *App) addATEMHost(ctx context.Context, action string, contextID string,
 	})
 
 	// 再接続ゴルーチンを開始
-	go a.reconnectionLoop(ctx, ip)
+	reconnectCtx, cancel := context.WithCancel(ctx)
+	instance.CancelReconnect = cancel
+	go a.reconnectionLoop(reconnectCtx, ip)
 	a.logger.Debug(ctx, "addATEMHost ip:%s 再接続ゴルーチンを開始", ip)
 	instance.ReconnectCh <- struct{}{}
 
 	return nil
 }
 
-// Run バックグラウンドプロセスを実行
+// handleStateEvent 状態変化イベントを購読しているContextに配送する
+func (a *App) handleStateEvent(ctx context.Context, ip string, instance *connectionmanager.ATEMInstance, ev state.Event) {
+	a.logger.Debug(ctx, "handleStateEvent ip:%s event:%#v", ip, ev)
+
+	// このATEMに紐づき、イベントを必要とするContextを描画する
+	for _, e := range a.registry.Subscribers(ip, ev.Type()) {
+		if act, ok := a.actions[e.Action]; ok {
+			act.render(ctx, e.Context, instance.State)
+		}
+	}
+}
+
+// Run StreamDeckとの通信を開始し、ctxが終了するかStreamDeckとの接続が切れるまでブロックする
+// 終了時には全てのATEMとの接続を閉じる
 func (a *App) Run(ctx context.Context) error {
-	return a.sd.Run(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer a.Shutdown(context.WithoutCancel(ctx))
+
+	a.logger.Info(ctx, "StreamDeckクライアントを開始")
+	if err := a.sd.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return xerrors.Errorf("StreamDeckクライアントの実行に失敗: %w", err)
+	}
+	return nil
+}
+
+// Shutdown 全てのサービスを停止し、全てのATEMとの接続を閉じる
+func (a *App) Shutdown(ctx context.Context) {
+	a.logger.Info(ctx, "シャットダウン中...")
+	a.stopServices(ctx)
+	a.connectionManager.CloseAll(ctx)
+}
+
+// pluginPath 出力先のパスを解決する。空の場合はdefaultDirとし、相対パスはプラグインのフォルダからのパスとする
+func (a *App) pluginPath(dir, defaultDir string) string {
+	if dir == "" {
+		dir = defaultDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(a.pluginDir, dir)
 }
 
 // setupSD StreamDeckクライアントをセットアップ
 func (a *App) setupSD() {
-	setPreviewAction := a.sd.Action(setPreviewAction)
-	setPreviewAction.RegisterHandler(streamdeck.KeyDown, a.PRVKeyDownHandler)
-	setPreviewAction.RegisterHandler(streamdeck.WillAppear, a.PRVWillAppearHandler)
-	setPreviewAction.RegisterHandler(streamdeck.WillDisappear, a.PRVWillDisappearHandler)
-	setPreviewAction.RegisterHandler(streamdeck.DidReceiveSettings, a.PRVDidReceiveSettingsHandler)
-
-	setProgramAction := a.sd.Action(setProgramAction)
-	setProgramAction.RegisterHandler(streamdeck.KeyDown, a.PGMKeyDownHandler)
-	setProgramAction.RegisterHandler(streamdeck.WillAppear, a.PGMWillAppearHandler)
-	setProgramAction.RegisterHandler(streamdeck.WillDisappear, a.PGMWillDisappearHandler)
-	setProgramAction.RegisterHandler(streamdeck.DidReceiveSettings, a.PGMDidReceiveSettingsHandler)
-
-	cutAction := a.sd.Action(cutAction)
-	cutAction.RegisterHandler(streamdeck.KeyDown, a.CutKeyDownHandler)
-	cutAction.RegisterHandler(streamdeck.WillAppear, a.CutWillAppearHandler)
-	cutAction.RegisterHandler(streamdeck.WillDisappear, a.CutWillDisappearHandler)
-	cutAction.RegisterHandler(streamdeck.DidReceiveSettings, a.CutDidReceiveSettingsHandler)
-
-	autoAction := a.sd.Action(autoAction)
-	autoAction.RegisterHandler(streamdeck.KeyDown, a.AutoKeyDownHandler)
-	autoAction.RegisterHandler(streamdeck.WillAppear, a.AutoWillAppearHandler)
-	autoAction.RegisterHandler(streamdeck.WillDisappear, a.AutoWillDisappearHandler)
-	autoAction.RegisterHandler(streamdeck.DidReceiveSettings, a.AutoDidReceiveSettingsHandler)
-
+	a.sd.RegisterNoActionHandler(streamdeck.DidReceiveGlobalSettings, a.DidReceiveGlobalSettingsHandler)
+	a.sd.RegisterNoActionHandler(streamdeck.DeviceDidConnect, a.DeviceDidConnectHandler)
+
+	registerAction(a, a.previewActionDef())
+	registerAction(a, a.programActionDef())
+	registerAction(a, a.inputActionDef())
+	registerAction(a, a.cutActionDef())
+	registerAction(a, a.autoActionDef())
+	registerAction(a, a.ftbActionDef())
+	registerAction(a, a.diagnosticsActionDef())
+	registerAction(a, a.lockActionDef())
 }
 
 // reconnectionLoop 特定のATEMホストの自動再接続を処理
@@ -227,7 +262,9 @@ func (a *App) reconnectionLoop(ctx context.Context, ip string) {
 			return
 		case <-instance.ReconnectCh:
 			a.logger.Debug(ctx, "reconnectionLoop ip:%s 再接続をトリガーしました", ip)
+			a.metrics.reconnects.Inc(ip)
 			if err := instance.Client.Connect(); err != nil {
+				a.tracker.Error(ip, xerrors.Errorf("接続に失敗: %w", err))
 				// 再試行前に待機
 				time.Sleep(5 * time.Second)
 				// 再試行
@@ -341,17 +378,6 @@ func solveATEMVideoInput(input int64) atem.VideoInputType {
 
 func (a *App) handleDisappear(ctx context.Context, contextID string) {
 	a.logger.Debug(ctx, "handleDisappear contextID:%s", contextID)
+	a.registry.Delete(contextID)
 	a.connectionManager.DeleteATEMByContext(ctx, contextID)
 }
-
-// Run アプリケーションを初期化して実行
-func Run(ctx context.Context, logger logger.Logger, sd *streamdeck.Client) error {
-	// アプリケーションを初期化
-	app, err := NewApp(ctx, logger, sd)
-	if err != nil {
-		return fmt.Errorf("アプリの初期化に失敗: %w", err)
-	}
-
-	// アプリケーションを実行
-	return app.Run(ctx)
-}
@@ -0,0 +1,337 @@
+package stdatem
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/FlowingSPDG/streamdeck"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/atemclient"
+	"github.com/FlowingSPDG/std-atem/Source/code/atemsim"
+	"github.com/FlowingSPDG/std-atem/Source/code/logger"
+	"github.com/FlowingSPDG/std-atem/Source/code/streamdecktest"
+)
+
+// testTimeout 応答を待つ時間
+const testTimeout = 10 * time.Second
+
+// startSimulator 空いているポートでatemsimを起動する
+func startSimulator(t *testing.T) atemsim.Server {
+	t.Helper()
+	sim, err := atemsim.NewServer(atemsim.Config{Address: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sim.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return sim
+}
+
+// startPlugin ハーネスに接続したAppを起動し、グローバル設定の受信まで済ませる
+func startPlugin(t *testing.T) *streamdecktest.Host {
+	t.Helper()
+	host, err := streamdecktest.NewHost()
+	if err != nil {
+		t.Fatalf("NewHost: %v", err)
+	}
+	params, err := streamdeck.ParseRegistrationParams(host.Args())
+	if err != nil {
+		t.Fatalf("ParseRegistrationParams: %v", err)
+	}
+
+	// 再接続ループなどはテスト終了後にもログを出すため、t.Logfは使わない
+	lg := logger.New(&slog.LevelVar{}, slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	sd := streamdeck.NewClient(ctx, params)
+	app, err := NewApp(ctx, lg, sd, params.PluginUUID, t.TempDir(), t.TempDir(), atemclient.NewATEMClient)
+	if err != nil {
+		cancel()
+		t.Fatalf("NewApp: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		host.Close()
+	})
+
+	wctx, wcancel := context.WithTimeout(ctx, testTimeout)
+	defer wcancel()
+	if err := host.WaitForRegistration(wctx); err != nil {
+		t.Fatalf("WaitForRegistration: %v", err)
+	}
+	if err := host.DidReceiveGlobalSettings(wctx, map[string]any{}); err != nil {
+		t.Fatalf("DidReceiveGlobalSettings: %v", err)
+	}
+	return host
+}
+
+// waitImage contextIDにimageが設定されるまで待つ
+func waitImage(ctx context.Context, t *testing.T, host *streamdecktest.Host, contextID, image, name string) {
+	t.Helper()
+	_, err := host.WaitFor(ctx, func(m streamdecktest.Message) bool {
+		return m.Event == "setImage" && m.Context == contextID && m.Image() == image
+	})
+	if err != nil {
+		t.Fatalf("%s のsetImageを受信できません: %v", name, err)
+	}
+}
+
+// waitTitle contextIDにtitleが設定されるまで待つ
+func waitTitle(ctx context.Context, t *testing.T, host *streamdecktest.Host, contextID, title string) {
+	t.Helper()
+	_, err := host.WaitFor(ctx, func(m streamdecktest.Message) bool {
+		return m.Event == "setTitle" && m.Context == contextID && m.Title() == title
+	})
+	if err != nil {
+		t.Fatalf("setTitle %q を受信できません: %v", title, err)
+	}
+}
+
+// waitEvent contextIDにeventが送られるまで待つ
+func waitEvent(ctx context.Context, t *testing.T, host *streamdecktest.Host, event, contextID string) {
+	t.Helper()
+	if _, err := host.WaitForEvent(ctx, event, contextID); err != nil {
+		t.Fatalf("%s を受信できません: %v", event, err)
+	}
+}
+
+func TestTallyRoundTrip(t *testing.T) {
+	sim := startSimulator(t)
+	host := startPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	settings := map[string]any{"version": 3, "ip": sim.Addr().String(), "input": 3, "meIndex": 0, "tallyMode": 1}
+	if err := host.WillAppear(ctx, setProgramAction, "pgm", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("WillAppear: %v", err)
+	}
+
+	// 接続前でも接続後でも、PGMが3になればPGMのタリーになる
+	if err := sim.SetProgramInput(0, 3); err != nil {
+		t.Fatalf("SetProgramInput: %v", err)
+	}
+	waitImage(ctx, t, host, "pgm", tallyProgram, "PGM")
+
+	host.Reset()
+	if err := sim.SetProgramInput(0, 1); err != nil {
+		t.Fatalf("SetProgramInput: %v", err)
+	}
+	waitImage(ctx, t, host, "pgm", tallyInactive, "非選択")
+
+	host.Reset()
+	if err := sim.SetProgramInput(0, 3); err != nil {
+		t.Fatalf("SetProgramInput: %v", err)
+	}
+	waitImage(ctx, t, host, "pgm", tallyProgram, "PGM")
+}
+
+func TestPreviewKeyDown(t *testing.T) {
+	sim := startSimulator(t)
+	host := startPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	settings := map[string]any{"version": 3, "ip": sim.Addr().String(), "input": 2, "meIndex": 0, "tallyMode": 1}
+	if err := host.WillAppear(ctx, setPreviewAction, "pvw", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("WillAppear: %v", err)
+	}
+	waitImage(ctx, t, host, "pvw", tallyPreview, "PVW")
+
+	// 設定の変更で描画し直す
+	host.Reset()
+	settings["input"] = 4
+	if err := host.DidReceiveSettings(ctx, setPreviewAction, "pvw", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("DidReceiveSettings: %v", err)
+	}
+	waitImage(ctx, t, host, "pvw", tallyInactive, "非選択")
+
+	host.Reset()
+	if err := host.KeyDown(ctx, setPreviewAction, "pvw", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("KeyDown: %v", err)
+	}
+	waitEvent(ctx, t, host, "showOk", "pvw")
+	waitImage(ctx, t, host, "pvw", tallyPreview, "PVW")
+}
+
+func TestArmDoublePress(t *testing.T) {
+	sim := startSimulator(t)
+	host := startPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	settings := map[string]any{"version": 3, "ip": sim.Addr().String(), "input": 1, "meIndex": 0, "tallyMode": 1, "armMode": "double"}
+	if err := host.WillAppear(ctx, setProgramAction, "pgm", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("WillAppear: %v", err)
+	}
+	waitImage(ctx, t, host, "pgm", tallyProgram, "PGM")
+
+	host.Reset()
+	settings["input"] = 4
+	if err := host.DidReceiveSettings(ctx, setProgramAction, "pgm", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("DidReceiveSettings: %v", err)
+	}
+	waitImage(ctx, t, host, "pgm", tallyInactive, "非選択")
+
+	// 1回目は確定待ちになるだけで送信しない
+	host.Reset()
+	if err := host.KeyDown(ctx, setProgramAction, "pgm", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("KeyDown: %v", err)
+	}
+	waitImage(ctx, t, host, "pgm", armedImage, "確定待ち")
+	for _, m := range host.Messages() {
+		if m.Event == "showOk" {
+			t.Fatal("1回目の押下で送信しました")
+		}
+	}
+	if err := host.KeyUp(ctx, setProgramAction, "pgm", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("KeyUp: %v", err)
+	}
+
+	if err := host.KeyDown(ctx, setProgramAction, "pgm", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("KeyDown: %v", err)
+	}
+	waitEvent(ctx, t, host, "showOk", "pgm")
+	waitImage(ctx, t, host, "pgm", tallyProgram, "PGM")
+}
+
+func TestLockOverlay(t *testing.T) {
+	host := startPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	settings := map[string]any{"version": 3, "ip": "127.0.0.1"}
+	if err := host.WillAppear(ctx, cutAction, "cut", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("WillAppear: %v", err)
+	}
+	if err := host.Send(ctx, streamdecktest.Message{
+		Event:   streamdeck.TitleParametersDidChange,
+		Action:  cutAction,
+		Context: "cut",
+		Device:  streamdecktest.DeviceID,
+		Payload: []byte(`{"title":"CAM1","settings":{}}`),
+	}); err != nil {
+		t.Fatalf("titleParametersDidChange: %v", err)
+	}
+
+	if err := host.DidReceiveGlobalSettings(ctx, map[string]any{"lock": map[string]any{"mode": "locked"}}); err != nil {
+		t.Fatalf("DidReceiveGlobalSettings: %v", err)
+	}
+	waitTitle(ctx, t, host, "cut", "LOCKED")
+
+	if err := host.KeyDown(ctx, cutAction, "cut", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("KeyDown: %v", err)
+	}
+	waitEvent(ctx, t, host, "showAlert", "cut")
+
+	// 解除するとユーザーが設定したタイトルに戻す
+	if err := host.DidReceiveGlobalSettings(ctx, map[string]any{}); err != nil {
+		t.Fatalf("DidReceiveGlobalSettings: %v", err)
+	}
+	waitTitle(ctx, t, host, "cut", "CAM1")
+}
+
+func TestDiagnosticsKeyDownWithoutSwitcher(t *testing.T) {
+	host := startPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// 接続先が見つからないスイッチャーでも診断データを書き出す
+	settings := map[string]any{"version": 3, "switcher": "missing", "ip": ""}
+	if err := host.WillAppear(ctx, diagnosticsAction, "diag", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("WillAppear: %v", err)
+	}
+	if err := host.KeyDown(ctx, diagnosticsAction, "diag", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("KeyDown: %v", err)
+	}
+	waitEvent(ctx, t, host, "showOk", "diag")
+}
+
+func TestFTBKeyDownShowsNoOk(t *testing.T) {
+	sim := startSimulator(t)
+	host := startPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	settings := map[string]any{"version": 3, "ip": sim.Addr().String(), "input": 2, "meIndex": 0, "tallyMode": 1}
+	if err := host.WillAppear(ctx, ftbAction, "ftb", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("WillAppear: %v", err)
+	}
+	if err := host.WillAppear(ctx, setPreviewAction, "pvw", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("WillAppear: %v", err)
+	}
+	waitImage(ctx, t, host, "pvw", tallyPreview, "PVW")
+
+	// FTBの反映は確認できないため、送信しても完了を表示しない
+	host.Reset()
+	if err := host.KeyDown(ctx, ftbAction, "ftb", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("KeyDown: %v", err)
+	}
+	// 後に押したPVWの完了が届いた時点で、FTBの表示も送信済みになっている
+	if err := host.KeyDown(ctx, setPreviewAction, "pvw", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("KeyDown: %v", err)
+	}
+	waitEvent(ctx, t, host, "showOk", "pvw")
+	for _, m := range host.Messages() {
+		if m.Context == "ftb" && (m.Event == "showOk" || m.Event == "showAlert") {
+			t.Errorf("FTBの送信後に %s を表示しました", m.Event)
+		}
+	}
+}
+
+func TestRehearsalSimulator(t *testing.T) {
+	sim := startSimulator(t)
+	host := startPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	settings := map[string]any{"version": 3, "ip": sim.Addr().String(), "input": 4, "meIndex": 0, "tallyMode": 1}
+	if err := host.WillAppear(ctx, setPreviewAction, "pvw", streamdecktest.Coordinates{}, settings); err != nil {
+		t.Fatalf("WillAppear: %v", err)
+	}
+	waitImage(ctx, t, host, "pvw", tallyInactive, "非選択")
+
+	// 送信先を指定しない場合は、空いているポートで起動した内蔵シミュレーターに送信する
+	if err := host.DidReceiveGlobalSettings(ctx, map[string]any{"lock": map[string]any{"mode": "rehearsal"}}); err != nil {
+		t.Fatalf("DidReceiveGlobalSettings: %v", err)
+	}
+	for {
+		host.Reset()
+		if err := host.KeyDown(ctx, setPreviewAction, "pvw", streamdecktest.Coordinates{}, settings); err != nil {
+			t.Fatalf("KeyDown: %v", err)
+		}
+		m, err := host.WaitFor(ctx, func(m streamdecktest.Message) bool {
+			return m.Context == "pvw" && (m.Event == "showOk" || m.Event == "showAlert")
+		})
+		if err != nil {
+			t.Fatalf("リハーサル用のスイッチャーに送信できません: %v", err)
+		}
+		if m.Event == "showOk" {
+			break
+		}
+		// シミュレーターへの接続が完了するまではアラートになる
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// 本番のスイッチャーのPVWは変わらない
+	for _, m := range host.Messages() {
+		if m.Event == "setImage" && m.Context == "pvw" && m.Image() == tallyPreview {
+			t.Error("リハーサル中に本番のスイッチャーに送信しました")
+		}
+	}
+}
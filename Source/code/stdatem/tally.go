@@ -0,0 +1,73 @@
+package stdatem
+
+import (
+	"context"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"github.com/FlowingSPDG/streamdeck"
+	sdcontext "github.com/FlowingSPDG/streamdeck/context"
+)
+
+// tallyBus ボタンが操作するバス
+type tallyBus int
+
+const (
+	tallyBusPreview tallyBus = iota
+	tallyBusProgram
+)
+
+// tallyEvents タリーモードに応じて描画に必要な状態変化イベント
+func tallyEvents(mode setting.TallyMode, bus tallyBus) []state.EventType {
+	switch mode {
+	case setting.TallyModeDisabled:
+		return nil
+	case setting.TallyModeACTS:
+		return []state.EventType{state.EventPreview, state.EventProgram}
+	default:
+		if bus == tallyBusProgram {
+			return []state.EventType{state.EventProgram}
+		}
+		return []state.EventType{state.EventPreview}
+	}
+}
+
+// solveTallyImage タリーモードに応じて表示する画像を決定する
+func solveTallyImage(mode setting.TallyMode, bus tallyBus, input uint16, meIndex uint8, st state.Switcher) string {
+	program, hasProgram := st.Program(meIndex)
+	preview, hasPreview := st.Preview(meIndex)
+	onProgram := hasProgram && program == input
+	onPreview := hasPreview && preview == input
+
+	switch mode {
+	case setting.TallyModeDisabled:
+		return tallyInactive
+	case setting.TallyModeACTS:
+		// PGMのタリーを優先する
+		if onProgram {
+			return tallyProgram
+		}
+		if onPreview {
+			return tallyPreview
+		}
+		return tallyInactive
+	default:
+		if bus == tallyBusProgram && onProgram {
+			return tallyProgram
+		}
+		if bus == tallyBusPreview && onPreview {
+			return tallyPreview
+		}
+		return tallyInactive
+	}
+}
+
+// renderTally タリーモードに応じてボタンのタリーを描画する
+func (a *App) renderTally(ctx context.Context, contextID string, mode setting.TallyMode, bus tallyBus, input uint16, meIndex uint8, st state.Switcher) {
+	image := solveTallyImage(mode, bus, input, meIndex, st)
+	sdctx := sdcontext.WithContext(ctx, contextID)
+	if err := a.sd.SetImage(sdctx, image, streamdeck.HardwareAndSoftware); err != nil {
+		a.logger.Error(ctx, "renderTally 画像の設定に失敗: %v", err)
+		a.sdSendFailed(streamdeck.SetImage)
+	}
+}
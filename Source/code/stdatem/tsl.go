@@ -0,0 +1,92 @@
+package stdatem
+
+import (
+	"context"
+	"slices"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+	"github.com/FlowingSPDG/std-atem/Source/code/tsl"
+)
+
+// tslServiceName TSL UMD送信のサービス名
+const tslServiceName = "tsl"
+
+// tslService 起動中のTSL UMD送信
+type tslService struct {
+	settings setting.TSLSettings
+	sender   tsl.Sender
+}
+
+// applyTSL グローバル設定に従ってTSL UMD送信を起動・停止する
+// 送信先等が変化した場合は再起動し、スイッチャーのみの変化は紐付け直す
+func (a *App) applyTSL(ctx context.Context) {
+	settings := a.globalSettings.Load().TSLSettings()
+
+	if a.tsl != nil && !sameTSLSender(a.tsl.settings, settings) {
+		a.stopTSL(ctx)
+	}
+	if !settings.Enabled {
+		return
+	}
+
+	if a.tsl == nil {
+		destinations := make([]tsl.Destination, 0, len(settings.Destinations))
+		for _, s := range settings.Destinations {
+			d, err := tsl.ParseDestination(s)
+			if err != nil {
+				a.logger.Warn(ctx, "TSL 送信先を無視します: %v", err)
+				continue
+			}
+			destinations = append(destinations, d)
+		}
+		sender, err := tsl.NewSender(tsl.Config{
+			Destinations:  destinations,
+			MeIndex:       uint8(settings.MeIndex),
+			Inputs:        settings.Inputs,
+			AddressOffset: settings.AddressOffset,
+			Logger:        a.logger.With("service", "tsl"),
+		})
+		if err != nil {
+			a.logger.Error(ctx, "TSL 送信の開始に失敗: %v", err)
+			return
+		}
+		a.logger.Info(ctx, "TSL 送信を開始 送信先:%v", destinations)
+		go func() {
+			if err := sender.Run(ctx); err != nil {
+				a.logger.Error(ctx, "TSL 送信が終了しました: %v", err)
+			}
+		}()
+		a.tsl = &tslService{sender: sender}
+	}
+	a.tsl.settings = settings
+
+	instance, ok := a.acquireSwitcher(ctx, tslServiceName, settings.Switcher)
+	if !ok {
+		a.logger.Warn(ctx, "TSL 送信 スイッチャー %q の接続先が見つかりません", settings.Switcher)
+		a.tsl.sender.SetSwitcher(nil)
+		return
+	}
+	a.tsl.sender.SetSwitcher(instance.State)
+}
+
+// stopTSL TSL UMD送信を停止する
+func (a *App) stopTSL(ctx context.Context) {
+	if a.tsl == nil {
+		return
+	}
+	if err := a.tsl.sender.Close(); err != nil {
+		a.logger.Warn(ctx, "TSL 送信の停止に失敗: %v", err)
+	}
+	a.releaseSwitcher(ctx, tslServiceName)
+	a.tsl = nil
+	a.logger.Info(ctx, "TSL 送信を停止")
+}
+
+// sameTSLSender 再起動せずに設定を反映できるか。スイッチャー以外が一致する場合にtrueを返す
+func sameTSLSender(x, y setting.TSLSettings) bool {
+	return x.Enabled == y.Enabled &&
+		x.MeIndex == y.MeIndex &&
+		x.Inputs == y.Inputs &&
+		x.AddressOffset == y.AddressOffset &&
+		slices.Equal(x.Destinations, y.Destinations)
+}
@@ -0,0 +1,85 @@
+package stdatem
+
+import (
+	"context"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/setting"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"github.com/FlowingSPDG/std-atem/Source/code/vmix"
+)
+
+// vmixServiceName vMix互換タリーサーバーのサービス名
+const vmixServiceName = "vmix"
+
+// vmixService 起動中のvMix互換タリーサーバー
+type vmixService struct {
+	settings setting.VMixSettings
+	server   vmix.Server
+	switcher state.Switcher // タリーの取得元として紐付けている状態キャッシュ
+}
+
+// applyVMix グローバル設定に従ってvMix互換タリーサーバーを起動・停止する
+// 待ち受けアドレス等が変化した場合は再起動し、接続先のスイッチャーが変化した場合のみ紐付け直す
+func (a *App) applyVMix(ctx context.Context) {
+	settings := a.globalSettings.Load().VMixSettings()
+
+	if a.vmix != nil && !sameVMixListener(a.vmix.settings, settings) {
+		a.stopVMix(ctx)
+	}
+	if !settings.Enabled {
+		return
+	}
+
+	if a.vmix == nil {
+		server, err := vmix.NewServer(vmix.Config{
+			TCPAddress:  settings.TCPAddress,
+			HTTPAddress: settings.HTTPAddress,
+			MeIndex:     uint8(settings.MeIndex),
+			Inputs:      settings.Inputs,
+			Logger:      a.logger.With("service", "vmix"),
+		})
+		if err != nil {
+			a.logger.Error(ctx, "vMix互換サーバーの起動に失敗: %v", err)
+			return
+		}
+		a.logger.Info(ctx, "vMix互換サーバーを開始 TCP:%s HTTP:%s", server.TCPAddr(), server.HTTPAddr())
+		go func() {
+			if err := server.Run(ctx); err != nil {
+				a.logger.Error(ctx, "vMix互換サーバーが終了しました: %v", err)
+			}
+		}()
+		a.vmix = &vmixService{server: server}
+	}
+	a.vmix.settings = settings
+
+	var st state.Switcher
+	if instance, ok := a.acquireSwitcher(ctx, vmixServiceName, settings.Switcher); ok {
+		st = instance.State
+	} else {
+		a.logger.Warn(ctx, "vMix互換サーバー スイッチャー %q の接続先が見つかりません", settings.Switcher)
+	}
+	if st == a.vmix.switcher {
+		return
+	}
+	a.vmix.switcher = st
+	a.vmix.server.SetSwitcher(st)
+}
+
+// stopVMix vMix互換タリーサーバーを停止する
+func (a *App) stopVMix(ctx context.Context) {
+	if a.vmix == nil {
+		return
+	}
+	if err := a.vmix.server.Close(); err != nil {
+		a.logger.Warn(ctx, "vMix互換サーバーの停止に失敗: %v", err)
+	}
+	a.releaseSwitcher(ctx, vmixServiceName)
+	a.vmix = nil
+	a.logger.Info(ctx, "vMix互換サーバーを停止")
+}
+
+// sameVMixListener 再起動せずに設定を反映できるか。スイッチャー以外が一致する場合にtrueを返す
+func sameVMixListener(x, y setting.VMixSettings) bool {
+	x.Switcher, y.Switcher = "", ""
+	return x == y
+}
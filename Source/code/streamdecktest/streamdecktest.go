@@ -0,0 +1,295 @@
+// Package streamdecktest Stream Deckアプリケーションを模倣し、プラグインを結合テストするためのハーネス
+//
+// Hostはプラグイン登録とイベントのWebSocketプロトコルを実装する。
+// Args()をstreamdeck.ParseRegistrationParamsに渡して作成したクライアントでAppを起動し、
+// WillAppear/KeyDown/DidReceiveSettingsなどのイベントを送信した後、
+// WaitForでsetImage/setTitle/showAlertなどの応答を検証する。
+// ATEMの代わりにatemsimを起動すれば、タリーの往復まで確認できる。
+package streamdecktest
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"golang.org/x/xerrors"
+	"nhooyr.io/websocket"
+)
+
+const (
+	// PluginUUID 登録時に利用するプラグインUUID
+	PluginUUID = "streamdecktest-plugin"
+	// RegisterEvent 登録イベント名
+	RegisterEvent = "registerPlugin"
+	// DeviceID 模倣するデバイスのID
+	DeviceID = "streamdecktest-device"
+)
+
+// info 登録時にプラグインへ渡すアプリケーション情報
+const info = `{"application":{"language":"en","platform":"mac","version":"6.8.0"},"plugin":{"uuid":"dev.flowingspdg.atem","version":"0.1.0"},"devicePixelRatio":2,"colors":{},"devices":[{"id":"` + DeviceID + `","name":"Stream Deck","size":{"columns":5,"rows":3},"type":0}]}`
+
+// Message プラグインから受信したメッセージ
+type Message struct {
+	Event   string          `json:"event"`
+	Action  string          `json:"action,omitempty"`
+	Context string          `json:"context,omitempty"`
+	Device  string          `json:"device,omitempty"`
+	UUID    string          `json:"uuid,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Image setImageのimageを取得する
+func (m Message) Image() string {
+	var payload struct {
+		Image string `json:"image"`
+	}
+	json.Unmarshal(m.Payload, &payload)
+	return payload.Image
+}
+
+// Title setTitleのtitleを取得する
+func (m Message) Title() string {
+	var payload struct {
+		Title string `json:"title"`
+	}
+	json.Unmarshal(m.Payload, &payload)
+	return payload.Title
+}
+
+// Coordinates ボタンの位置
+type Coordinates struct {
+	Column int `json:"column"`
+	Row    int `json:"row"`
+}
+
+// Host Stream Deckアプリケーションを模倣するWebSocketサーバー
+type Host struct {
+	listener net.Listener
+	server   *http.Server
+
+	mu         sync.Mutex
+	conn       *websocket.Conn
+	registered chan struct{}
+	messages   []Message
+	notify     chan struct{} // メッセージ受信のたびに閉じて作り直す
+}
+
+// NewHost ローカルの空きポートでHostを起動する
+func NewHost() (*Host, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, xerrors.Errorf("待ち受けに失敗: %w", err)
+	}
+
+	h := &Host{
+		listener:   listener,
+		registered: make(chan struct{}),
+		notify:     make(chan struct{}),
+	}
+	h.server = &http.Server{Handler: http.HandlerFunc(h.handle)}
+	go h.server.Serve(listener)
+	return h, nil
+}
+
+// Port 待ち受けポート
+func (h *Host) Port() int {
+	return h.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Args streamdeck.ParseRegistrationParamsに渡すコマンドライン引数
+func (h *Host) Args() []string {
+	return []string{
+		"plugin",
+		"-port", strconv.Itoa(h.Port()),
+		"-pluginUUID", PluginUUID,
+		"-registerEvent", RegisterEvent,
+		"-info", info,
+	}
+}
+
+// Close Hostを停止する
+func (h *Host) Close() error {
+	h.mu.Lock()
+	if h.conn != nil {
+		h.conn.Close(websocket.StatusNormalClosure, "")
+	}
+	h.mu.Unlock()
+	return h.server.Close()
+}
+
+func (h *Host) handle(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{InsecureSkipVerify: true})
+	if err != nil {
+		return
+	}
+	defer conn.Close(websocket.StatusInternalError, "")
+
+	ctx := r.Context()
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		h.mu.Lock()
+		if msg.Event == RegisterEvent && h.conn == nil {
+			h.conn = conn
+			close(h.registered)
+		} else {
+			h.messages = append(h.messages, msg)
+		}
+		close(h.notify)
+		h.notify = make(chan struct{})
+		h.mu.Unlock()
+	}
+}
+
+// WaitForRegistration プラグインが登録イベントを送信するまで待機する
+func (h *Host) WaitForRegistration(ctx context.Context) error {
+	select {
+	case <-h.registered:
+		return nil
+	case <-ctx.Done():
+		return xerrors.Errorf("プラグインの登録を待機中にタイムアウト: %w", ctx.Err())
+	}
+}
+
+// Messages これまでにプラグインから受信したメッセージ
+func (h *Host) Messages() []Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Message(nil), h.messages...)
+}
+
+// Reset 受信済みメッセージを破棄する
+func (h *Host) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = nil
+}
+
+// WaitFor matchを満たすメッセージを受信するまで待機する。受信済みのメッセージも対象になる
+func (h *Host) WaitFor(ctx context.Context, match func(Message) bool) (Message, error) {
+	seen := 0
+	for {
+		h.mu.Lock()
+		if seen > len(h.messages) {
+			// Resetされた場合は先頭から見直す
+			seen = 0
+		}
+		pending := h.messages[seen:]
+		seen = len(h.messages)
+		notify := h.notify
+		h.mu.Unlock()
+
+		for _, msg := range pending {
+			if match(msg) {
+				return msg, nil
+			}
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return Message{}, xerrors.Errorf("メッセージの待機中にタイムアウト: %w", ctx.Err())
+		}
+	}
+}
+
+// WaitForEvent 指定したContextに対するイベントを受信するまで待機する
+func (h *Host) WaitForEvent(ctx context.Context, event, contextID string) (Message, error) {
+	return h.WaitFor(ctx, func(m Message) bool {
+		return m.Event == event && m.Context == contextID
+	})
+}
+
+// Send 任意のイベントをプラグインに送信する
+func (h *Host) Send(ctx context.Context, msg Message) error {
+	h.mu.Lock()
+	conn := h.conn
+	h.mu.Unlock()
+	if conn == nil {
+		return xerrors.New("プラグインが登録されていません")
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return xerrors.Errorf("メッセージのマーシャルに失敗: %w", err)
+	}
+	if err := conn.Write(ctx, websocket.MessageText, data); err != nil {
+		return xerrors.Errorf("メッセージの送信に失敗: %w", err)
+	}
+	return nil
+}
+
+// sendAction アクションのContextに紐づくイベントを送信する
+func (h *Host) sendAction(ctx context.Context, event, action, contextID string, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return xerrors.Errorf("payloadのマーシャルに失敗: %w", err)
+	}
+	return h.Send(ctx, Message{
+		Event:   event,
+		Action:  action,
+		Context: contextID,
+		Device:  DeviceID,
+		Payload: raw,
+	})
+}
+
+type actionPayload struct {
+	Settings        any         `json:"settings"`
+	Coordinates     Coordinates `json:"coordinates"`
+	State           int         `json:"state"`
+	IsInMultiAction bool        `json:"isInMultiAction"`
+}
+
+// WillAppear ボタンの表示を通知する
+func (h *Host) WillAppear(ctx context.Context, action, contextID string, coordinates Coordinates, settings any) error {
+	return h.sendAction(ctx, "willAppear", action, contextID, actionPayload{Settings: settings, Coordinates: coordinates})
+}
+
+// WillDisappear ボタンの非表示を通知する
+func (h *Host) WillDisappear(ctx context.Context, action, contextID string, coordinates Coordinates, settings any) error {
+	return h.sendAction(ctx, "willDisappear", action, contextID, actionPayload{Settings: settings, Coordinates: coordinates})
+}
+
+// KeyDown ボタンの押下を通知する
+func (h *Host) KeyDown(ctx context.Context, action, contextID string, coordinates Coordinates, settings any) error {
+	return h.sendAction(ctx, "keyDown", action, contextID, actionPayload{Settings: settings, Coordinates: coordinates})
+}
+
+// KeyUp ボタンの解放を通知する
+func (h *Host) KeyUp(ctx context.Context, action, contextID string, coordinates Coordinates, settings any) error {
+	return h.sendAction(ctx, "keyUp", action, contextID, actionPayload{Settings: settings, Coordinates: coordinates})
+}
+
+// DidReceiveSettings Property Inspectorからの設定変更を通知する
+func (h *Host) DidReceiveSettings(ctx context.Context, action, contextID string, coordinates Coordinates, settings any) error {
+	return h.sendAction(ctx, "didReceiveSettings", action, contextID, actionPayload{Settings: settings, Coordinates: coordinates})
+}
+
+// DidReceiveGlobalSettings グローバル設定を通知する
+func (h *Host) DidReceiveGlobalSettings(ctx context.Context, settings any) error {
+	raw, err := json.Marshal(map[string]any{"settings": settings})
+	if err != nil {
+		return xerrors.Errorf("payloadのマーシャルに失敗: %w", err)
+	}
+	return h.Send(ctx, Message{
+		Event:   "didReceiveGlobalSettings",
+		Context: PluginUUID,
+		Payload: raw,
+	})
+}
+
+// SendToPlugin Property Inspectorからプラグインへのメッセージを送信する
+func (h *Host) SendToPlugin(ctx context.Context, action, contextID string, payload any) error {
+	return h.sendAction(ctx, "sendToPlugin", action, contextID, payload)
+}
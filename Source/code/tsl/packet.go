@@ -0,0 +1,129 @@
+package tsl
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// Tally 1つのUMDに表示するタリー
+type Tally struct {
+	Address uint16 // UMDのアドレス(TSL 5.0ではINDEX)
+	Program bool
+	Preview bool
+	Text    string
+}
+
+const (
+	// v31DisplayLength TSL 3.1の表示データの長さ
+	v31DisplayLength = 16
+	// v31MaxAddress TSL 3.1で指定できるアドレスの最大値
+	v31MaxAddress = 126
+	// v31Brightness 最大輝度
+	v31Brightness = 3
+
+	// v5Brightness 最大輝度
+	v5Brightness = 3
+	// v5FlagUnicode テキストをUTF-16LEで送る
+	v5FlagUnicode = 0x01
+	// v5DLE, v5STX TCPで利用するパケットの区切り
+	v5DLE = 0xFE
+	v5STX = 0x02
+)
+
+// v5 タリーの色
+const (
+	v5TallyOff   = 0
+	v5TallyRed   = 1
+	v5TallyGreen = 2
+	v5TallyAmber = 3
+)
+
+// marshalV31 TSL 3.1のパケット(18バイト)を生成する
+// tally1をPGM、tally2をPVWとして送信する
+func marshalV31(t Tally) ([]byte, bool) {
+	if t.Address > v31MaxAddress {
+		return nil, false
+	}
+	b := make([]byte, 2+v31DisplayLength)
+	b[0] = 0x80 + byte(t.Address)
+
+	control := byte(v31Brightness << 4)
+	if t.Program {
+		control |= 0x01
+	}
+	if t.Preview {
+		control |= 0x02
+	}
+	b[1] = control
+
+	// 表示データは印字可能なASCIIのみ。残りは空白で埋める
+	for i := range v31DisplayLength {
+		b[2+i] = ' '
+	}
+	i := 0
+	for _, r := range t.Text {
+		if i >= v31DisplayLength {
+			break
+		}
+		if r < 0x20 || r > 0x7E {
+			r = '?'
+		}
+		b[2+i] = byte(r)
+		i++
+	}
+	return b, true
+}
+
+// v5Color PGM/PVWからTSL 5.0のタリーの色を決める
+func v5Color(t Tally) uint16 {
+	switch {
+	case t.Program && t.Preview:
+		return v5TallyAmber
+	case t.Program:
+		return v5TallyRed
+	case t.Preview:
+		return v5TallyGreen
+	default:
+		return v5TallyOff
+	}
+}
+
+// marshalV5 TSL 5.0のパケットを生成する。テキストはUTF-16LEで送信する
+func marshalV5(screen uint16, tallies []Tally) []byte {
+	body := make([]byte, 0, 64*len(tallies))
+	body = append(body, 0)             // VER
+	body = append(body, v5FlagUnicode) // FLAGS
+	body = binary.LittleEndian.AppendUint16(body, screen)
+
+	for _, t := range tallies {
+		color := v5Color(t)
+		// RHタリー、テキストタリー、LHタリーに同じ色を設定する
+		control := color | color<<2 | color<<4 | v5Brightness<<6
+
+		text := utf16.Encode([]rune(t.Text))
+		body = binary.LittleEndian.AppendUint16(body, t.Address)
+		body = binary.LittleEndian.AppendUint16(body, control)
+		body = binary.LittleEndian.AppendUint16(body, uint16(len(text)*2))
+		for _, c := range text {
+			body = binary.LittleEndian.AppendUint16(body, c)
+		}
+	}
+
+	// PBCは自身を含まないバイト数
+	b := make([]byte, 0, 2+len(body))
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(body)))
+	return append(b, body...)
+}
+
+// wrapV5TCP TCPで送信するためにDLE/STXを付与し、本文中のDLEをエスケープする
+func wrapV5TCP(packet []byte) []byte {
+	b := make([]byte, 0, len(packet)+2)
+	b = append(b, v5DLE, v5STX)
+	for _, c := range packet {
+		if c == v5DLE {
+			b = append(b, v5DLE)
+		}
+		b = append(b, c)
+	}
+	return b
+}
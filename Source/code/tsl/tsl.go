@@ -0,0 +1,302 @@
+// Package tsl ATEMのPGM/PVWのタリーと入力名をTSL UMD 3.1 / 5.0で送信する
+//
+// UMDのアドレスは入力番号にAddressOffsetを加えた値になる。
+// UDPは取りこぼしに備えて状態変化時に加えて定期的に全てのタリーを再送する。
+package tsl
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/logger"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"golang.org/x/xerrors"
+)
+
+// Version TSL UMDプロトコルのバージョン
+type Version string
+
+const (
+	Version31 Version = "3.1"
+	Version50 Version = "5.0"
+)
+
+const (
+	// DefaultInputs 送信する入力の数の既定値
+	DefaultInputs = 8
+	// DefaultInterval 定期的に再送する間隔の既定値
+	DefaultInterval = time.Second
+
+	// dialTimeout TCP接続のタイムアウト
+	dialTimeout = 3 * time.Second
+	// writeTimeout 送信のタイムアウト
+	writeTimeout = 2 * time.Second
+)
+
+// Destination 送信先
+type Destination struct {
+	Network string  // "udp"または"tcp"
+	Address string  // "host:port"
+	Version Version // プロトコルのバージョン
+	Screen  uint16  // TSL 5.0のSCREEN
+}
+
+// String "udp://host:port?version=5.0"の形式
+func (d Destination) String() string {
+	u := url.URL{Scheme: d.Network, Host: d.Address}
+	q := url.Values{"version": []string{string(d.Version)}}
+	if d.Screen != 0 {
+		q.Set("screen", strconv.Itoa(int(d.Screen)))
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// ParseDestination "udp://host:port?version=3.1"や"tcp://host:port?version=5.0&screen=1"を解析する
+// versionを省略した場合は5.0として扱う
+func ParseDestination(s string) (Destination, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return Destination{}, xerrors.Errorf("送信先 %q の解析に失敗: %w", s, err)
+	}
+	d := Destination{
+		Network: strings.ToLower(u.Scheme),
+		Address: u.Host,
+		Version: Version50,
+	}
+	if d.Network != "udp" && d.Network != "tcp" {
+		return Destination{}, xerrors.Errorf("送信先 %q のプロトコル %q には対応していません", s, u.Scheme)
+	}
+	if _, _, err := net.SplitHostPort(d.Address); err != nil {
+		return Destination{}, xerrors.Errorf("送信先 %q のアドレスが不正です: %w", s, err)
+	}
+
+	q := u.Query()
+	switch v := q.Get("version"); v {
+	case "":
+	case "3.1", "31", "3":
+		d.Version = Version31
+	case "5.0", "50", "5":
+		d.Version = Version50
+	default:
+		return Destination{}, xerrors.Errorf("送信先 %q のバージョン %q には対応していません", s, v)
+	}
+	if screen := q.Get("screen"); screen != "" {
+		n, err := strconv.ParseUint(screen, 10, 16)
+		if err != nil {
+			return Destination{}, xerrors.Errorf("送信先 %q のscreenが不正です: %w", s, err)
+		}
+		d.Screen = uint16(n)
+	}
+	return d, nil
+}
+
+// Config 送信の設定
+type Config struct {
+	Destinations  []Destination
+	MeIndex       uint8         // タリーを取得するM/E
+	Inputs        int           // 送信する入力の数。0の場合はDefaultInputs
+	AddressOffset int           // 入力番号に加えるUMDアドレスのオフセット
+	Interval      time.Duration // 定期的に再送する間隔。0の場合はDefaultInterval
+	Logger        logger.Logger // nilの場合はログを出力しない
+}
+
+// Sender TSL UMDの送信
+type Sender interface {
+	// Run ctxが終了するかCloseされるまで送信を続ける
+	Run(ctx context.Context) error
+	Close() error
+	// SetSwitcher タリーの取得元を切り替える。nilの場合は全て消灯として扱う
+	SetSwitcher(st state.Switcher)
+}
+
+// NewSender Senderを初期化する
+func NewSender(cfg Config) (Sender, error) {
+	if len(cfg.Destinations) == 0 {
+		return nil, xerrors.New("送信先が指定されていません")
+	}
+	if cfg.Inputs <= 0 {
+		cfg.Inputs = DefaultInputs
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+
+	s := &sender{
+		cfg:     cfg,
+		targets: make([]*target, 0, len(cfg.Destinations)),
+		notify:  make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+	}
+	for _, d := range cfg.Destinations {
+		s.targets = append(s.targets, &target{dest: d})
+	}
+	return s, nil
+}
+
+type sender struct {
+	cfg     Config
+	targets []*target
+	notify  chan struct{} // 状態変化の通知
+
+	mu          sync.Mutex // 以下を保護する
+	switcher    state.Switcher
+	unsubscribe func()
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// target 送信先ごとの接続
+type target struct {
+	dest Destination
+	conn net.Conn // Runのゴルーチンからのみ操作する
+}
+
+func (s *sender) debug(ctx context.Context, format string, args ...any) {
+	if s.cfg.Logger == nil {
+		return
+	}
+	s.cfg.Logger.Debug(ctx, format, args...)
+}
+
+func (s *sender) SetSwitcher(st state.Switcher) {
+	s.mu.Lock()
+	if s.unsubscribe != nil {
+		s.unsubscribe()
+		s.unsubscribe = nil
+	}
+	s.switcher = st
+	if st != nil {
+		s.unsubscribe = st.Subscribe(func(state.Event) {
+			s.trigger()
+		}, state.EventProgram, state.EventPreview, state.EventInput, state.EventConnection)
+	}
+	s.mu.Unlock()
+	s.trigger()
+}
+
+// trigger 送信を要求する。送信待ちの要求がある場合はまとめる
+func (s *sender) trigger() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *sender) Run(ctx context.Context) error {
+	defer func() {
+		for _, t := range s.targets {
+			t.close()
+		}
+	}()
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.closed:
+			return nil
+		case <-s.notify:
+		case <-ticker.C:
+		}
+		s.send(ctx)
+	}
+}
+
+func (s *sender) Close() error {
+	s.closeOnce.Do(func() {
+		s.SetSwitcher(nil)
+		close(s.closed)
+	})
+	return nil
+}
+
+// tallies 現在のタリーを入力ごとに取得する
+func (s *sender) tallies() []Tally {
+	s.mu.Lock()
+	st := s.switcher
+	s.mu.Unlock()
+
+	var program, preview uint16
+	if st != nil {
+		if me, ok := st.MixEffect(s.cfg.MeIndex); ok {
+			program, preview = me.Program, me.Preview
+		}
+	}
+
+	tallies := make([]Tally, 0, s.cfg.Inputs)
+	for i := 1; i <= s.cfg.Inputs; i++ {
+		input := uint16(i)
+		address := i + s.cfg.AddressOffset
+		if address < 0 || address > 0xFFFF {
+			continue
+		}
+		tallies = append(tallies, Tally{
+			Address: uint16(address),
+			Program: input == program,
+			Preview: input == preview,
+			Text:    state.InputName(st, input).LongName,
+		})
+	}
+	return tallies
+}
+
+// send 全ての送信先に現在のタリーを送信する
+func (s *sender) send(ctx context.Context) {
+	tallies := s.tallies()
+	for _, t := range s.targets {
+		if err := t.send(tallies); err != nil {
+			s.debug(ctx, "%s への送信に失敗: %v", t.dest, err)
+			t.close()
+		}
+	}
+}
+
+func (t *target) send(tallies []Tally) error {
+	if t.conn == nil {
+		conn, err := net.DialTimeout(t.dest.Network, t.dest.Address, dialTimeout)
+		if err != nil {
+			return xerrors.Errorf("接続に失敗: %w", err)
+		}
+		t.conn = conn
+	}
+
+	var packets [][]byte
+	switch t.dest.Version {
+	case Version31:
+		for _, tally := range tallies {
+			if b, ok := marshalV31(tally); ok {
+				packets = append(packets, b)
+			}
+		}
+	default:
+		b := marshalV5(t.dest.Screen, tallies)
+		if t.dest.Network == "tcp" {
+			b = wrapV5TCP(b)
+		}
+		packets = append(packets, b)
+	}
+
+	t.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	for _, b := range packets {
+		if _, err := t.conn.Write(b); err != nil {
+			return xerrors.Errorf("送信に失敗: %w", err)
+		}
+	}
+	return nil
+}
+
+func (t *target) close() {
+	if t.conn != nil {
+		t.conn.Close()
+		t.conn = nil
+	}
+}
@@ -0,0 +1,363 @@
+// Package vmix ATEMの状態をvMix互換のTCP API(SUBSCRIBE TALLY)とHTTP XML APIとして公開する
+//
+// vMix用のタリーランプやソフトウェアから、このプラグイン経由でATEMのタリーを利用できる。
+// vMixのInput番号はATEMのソース番号(1からInputs)に対応する。
+package vmix
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/logger"
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"github.com/puzpuzpuz/xsync/v3"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// DefaultTCPAddress vMix TCP APIの標準ポート
+	DefaultTCPAddress = ":8099"
+	// DefaultHTTPAddress vMix HTTP APIの標準ポート
+	DefaultHTTPAddress = ":8088"
+	// DefaultInputs タリーに含めるInputの数の既定値
+	DefaultInputs = 8
+
+	// Version クライアントに通知するvMixのバージョン
+	Version = "27.0.0.0"
+)
+
+// Config サーバーの設定
+type Config struct {
+	TCPAddress  string        // 空の場合はDefaultTCPAddress
+	HTTPAddress string        // 空の場合はDefaultHTTPAddress
+	MeIndex     uint8         // タリーを取得するM/E
+	Inputs      int           // タリーに含めるInputの数。0の場合はDefaultInputs
+	Logger      logger.Logger // nilの場合はログを出力しない
+}
+
+// Server vMix互換のタリーサーバー
+type Server interface {
+	TCPAddr() net.Addr
+	HTTPAddr() net.Addr
+	// Run ctxが終了するまで接続を受け付ける
+	Run(ctx context.Context) error
+	Close() error
+	// SetSwitcher タリーの取得元を切り替える。nilの場合は全て消灯として扱う
+	SetSwitcher(st state.Switcher)
+}
+
+// NewServer サーバーを初期化し、TCPとHTTPのポートをbindする
+func NewServer(cfg Config) (Server, error) {
+	if cfg.TCPAddress == "" {
+		cfg.TCPAddress = DefaultTCPAddress
+	}
+	if cfg.HTTPAddress == "" {
+		cfg.HTTPAddress = DefaultHTTPAddress
+	}
+	if cfg.Inputs <= 0 {
+		cfg.Inputs = DefaultInputs
+	}
+
+	tcp, err := net.Listen("tcp", cfg.TCPAddress)
+	if err != nil {
+		return nil, xerrors.Errorf("TCPの待ち受けに失敗: %w", err)
+	}
+	httpListener, err := net.Listen("tcp", cfg.HTTPAddress)
+	if err != nil {
+		tcp.Close()
+		return nil, xerrors.Errorf("HTTPの待ち受けに失敗: %w", err)
+	}
+
+	s := &server{
+		cfg:     cfg,
+		tcp:     tcp,
+		http:    httpListener,
+		clients: xsync.NewMapOf[*client, struct{}](),
+		closed:  make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api", s.handleAPI)
+	mux.HandleFunc("GET /api/", s.handleAPI)
+	mux.HandleFunc("GET /API", s.handleAPI)
+	mux.HandleFunc("GET /API/", s.handleAPI)
+	s.httpServer = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	return s, nil
+}
+
+type server struct {
+	cfg        Config
+	tcp        net.Listener
+	http       net.Listener
+	httpServer *http.Server
+	clients    *xsync.MapOf[*client, struct{}]
+
+	mu          sync.Mutex // 以下を保護する
+	switcher    state.Switcher
+	unsubscribe func()
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// client TCP APIの接続
+type client struct {
+	conn net.Conn
+	mu   sync.Mutex // 書き込みを保護する
+
+	tally atomic.Bool // SUBSCRIBE TALLY済み
+	acts  atomic.Bool // SUBSCRIBE ACTS済み
+}
+
+func (c *client) write(format string, args ...any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	_, err := fmt.Fprintf(c.conn, format, args...)
+	return err
+}
+
+func (s *server) TCPAddr() net.Addr {
+	return s.tcp.Addr()
+}
+
+func (s *server) HTTPAddr() net.Addr {
+	return s.http.Addr()
+}
+
+func (s *server) Run(ctx context.Context) error {
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case <-s.closed:
+		}
+	}()
+	go s.httpServer.Serve(s.http)
+
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return xerrors.Errorf("TCP接続の受け付けに失敗: %w", err)
+		}
+		go s.serveTCP(ctx, conn)
+	}
+}
+
+func (s *server) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.SetSwitcher(nil)
+		err = errors.Join(s.tcp.Close(), s.httpServer.Close())
+		s.clients.Range(func(c *client, _ struct{}) bool {
+			c.conn.Close()
+			return true
+		})
+	})
+	return err
+}
+
+func (s *server) debug(ctx context.Context, format string, args ...any) {
+	if s.cfg.Logger == nil {
+		return
+	}
+	s.cfg.Logger.Debug(ctx, format, args...)
+}
+
+func (s *server) SetSwitcher(st state.Switcher) {
+	s.mu.Lock()
+	if s.unsubscribe != nil {
+		s.unsubscribe()
+		s.unsubscribe = nil
+	}
+	s.switcher = st
+	if st != nil {
+		s.unsubscribe = st.Subscribe(s.handleEvent, state.EventProgram, state.EventPreview)
+	}
+	s.mu.Unlock()
+
+	// 取得元が変わったため、購読中のクライアントに現在のタリーを通知する
+	s.broadcastTally()
+}
+
+func (s *server) currentSwitcher() state.Switcher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.switcher
+}
+
+// handleEvent 状態変化を購読中のクライアントに通知する
+func (s *server) handleEvent(ev state.Event) {
+	switch ev := ev.(type) {
+	case state.ProgramChanged:
+		if ev.MeIndex != s.cfg.MeIndex {
+			return
+		}
+		s.broadcastActs("Input", ev.Previous, ev.Input)
+	case state.PreviewChanged:
+		if ev.MeIndex != s.cfg.MeIndex {
+			return
+		}
+		s.broadcastActs("InputPreview", ev.Previous, ev.Input)
+	default:
+		return
+	}
+	s.broadcastTally()
+}
+
+func (s *server) broadcastTally() {
+	tally := s.tally()
+	s.clients.Range(func(c *client, _ struct{}) bool {
+		if c.tally.Load() {
+			c.write("TALLY OK %s\r\n", tally)
+		}
+		return true
+	})
+}
+
+// broadcastActs ACTSを購読中のクライアントにInputの切り替えを通知する
+func (s *server) broadcastActs(name string, previous, current uint16) {
+	s.clients.Range(func(c *client, _ struct{}) bool {
+		if !c.acts.Load() {
+			return true
+		}
+		if s.inRange(previous) {
+			c.write("ACTS OK %s %d 0\r\n", name, previous)
+		}
+		if s.inRange(current) {
+			c.write("ACTS OK %s %d 1\r\n", name, current)
+		}
+		return true
+	})
+}
+
+func (s *server) inRange(input uint16) bool {
+	return input >= 1 && int(input) <= s.cfg.Inputs
+}
+
+// busState 現在のPGM/PVW。状態が不明な場合は0を返す
+func (s *server) busState() (program, preview uint16) {
+	st := s.currentSwitcher()
+	if st == nil {
+		return 0, 0
+	}
+	me, ok := st.MixEffect(s.cfg.MeIndex)
+	if !ok {
+		return 0, 0
+	}
+	return me.Program, me.Preview
+}
+
+// tally vMix形式のタリー文字列。Inputごとに0(消灯)、1(PGM)、2(PVW)を並べる
+func (s *server) tally() string {
+	program, preview := s.busState()
+	var b strings.Builder
+	for i := 1; i <= s.cfg.Inputs; i++ {
+		switch uint16(i) {
+		case program:
+			b.WriteByte('1')
+		case preview:
+			b.WriteByte('2')
+		default:
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+// serveTCP TCP APIの1接続を処理する
+func (s *server) serveTCP(ctx context.Context, conn net.Conn) {
+	c := &client{conn: conn}
+	s.clients.Store(c, struct{}{})
+	defer func() {
+		s.clients.Delete(c)
+		conn.Close()
+	}()
+	s.debug(ctx, "TCPクライアント %s が接続しました", conn.RemoteAddr())
+
+	if err := c.write("VERSION OK %s\r\n", Version); err != nil {
+		return
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := s.handleCommand(c, line); err != nil {
+			if errors.Is(err, errQuit) {
+				return
+			}
+			s.debug(ctx, "TCPクライアント %s への送信に失敗: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+var errQuit = xerrors.New("QUIT")
+
+// handleCommand TCP APIのコマンドを処理する
+func (s *server) handleCommand(c *client, line string) error {
+	fields := strings.Fields(line)
+	command := strings.ToUpper(fields[0])
+	var arg string
+	if len(fields) > 1 {
+		arg = strings.ToUpper(fields[1])
+	}
+
+	switch {
+	case command == "TALLY":
+		return c.write("TALLY OK %s\r\n", s.tally())
+	case command == "SUBSCRIBE" && arg == "TALLY":
+		c.tally.Store(true)
+		if err := c.write("SUBSCRIBE OK TALLY\r\n"); err != nil {
+			return err
+		}
+		return c.write("TALLY OK %s\r\n", s.tally())
+	case command == "SUBSCRIBE" && arg == "ACTS":
+		c.acts.Store(true)
+		return c.write("SUBSCRIBE OK ACTS\r\n")
+	case command == "UNSUBSCRIBE" && arg == "TALLY":
+		c.tally.Store(false)
+		return c.write("UNSUBSCRIBE OK TALLY\r\n")
+	case command == "UNSUBSCRIBE" && arg == "ACTS":
+		c.acts.Store(false)
+		return c.write("UNSUBSCRIBE OK ACTS\r\n")
+	case command == "XML":
+		xml, err := s.xml()
+		if err != nil {
+			return c.write("XML ER %s\r\n", err)
+		}
+		return c.write("XML %d\r\n%s", len(xml), xml)
+	case command == "VERSION":
+		return c.write("VERSION OK %s\r\n", Version)
+	case command == "QUIT":
+		c.write("QUIT OK Bye\r\n")
+		return errQuit
+	default:
+		return c.write("%s ER Unknown command\r\n", command)
+	}
+}
+
+// handleAPI HTTP APIで状態のXMLを返す
+func (s *server) handleAPI(w http.ResponseWriter, r *http.Request) {
+	xml, err := s.xml()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.Write(xml)
+}
@@ -0,0 +1,63 @@
+package vmix
+
+import (
+	"encoding/xml"
+	"strconv"
+
+	"github.com/FlowingSPDG/std-atem/Source/code/state"
+	"golang.org/x/xerrors"
+)
+
+// apiState vMixのHTTP APIが返すXMLのうち、タリー機器が参照する部分
+type apiState struct {
+	XMLName xml.Name   `xml:"vmix"`
+	Version string     `xml:"version"`
+	Edition string     `xml:"edition"`
+	Inputs  []apiInput `xml:"inputs>input"`
+	Active  int        `xml:"active"`
+	Preview int        `xml:"preview"`
+}
+
+// apiInput vMixのInput
+type apiInput struct {
+	Key    string `xml:"key,attr"`
+	Number int    `xml:"number,attr"`
+	Type   string `xml:"type,attr"`
+	Title  string `xml:"title,attr"`
+	State  string `xml:"state,attr"`
+	Name   string `xml:",chardata"`
+}
+
+// xml 現在の状態をvMix形式のXMLにする
+func (s *server) xml() ([]byte, error) {
+	program, preview := s.busState()
+	st := apiState{
+		Version: Version,
+		Edition: "4K",
+		Inputs:  make([]apiInput, 0, s.cfg.Inputs),
+	}
+	if s.inRange(program) {
+		st.Active = int(program)
+	}
+	if s.inRange(preview) {
+		st.Preview = int(preview)
+	}
+	switcher := s.currentSwitcher()
+	for i := 1; i <= s.cfg.Inputs; i++ {
+		title := state.InputName(switcher, uint16(i)).LongName
+		st.Inputs = append(st.Inputs, apiInput{
+			Key:    "atem-input-" + strconv.Itoa(i),
+			Number: i,
+			Type:   "Capture",
+			Title:  title,
+			State:  "Running",
+			Name:   title,
+		})
+	}
+
+	b, err := xml.Marshal(st)
+	if err != nil {
+		return nil, xerrors.Errorf("XMLのマーシャルに失敗: %w", err)
+	}
+	return b, nil
+}
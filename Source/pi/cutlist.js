@@ -0,0 +1,43 @@
+// ****************************************************************
+// * PGMの履歴(カットリスト)の書き出しと消去を行うProperty Inspector共通処理
+// *
+// * 対象はこのボタンが接続しているスイッチャー
+// ****************************************************************
+
+document.addEventListener('websocketCreate', function () {
+    websocket.addEventListener('message', function (evt) {
+        var jsonObj = JSON.parse(evt.data);
+        if (jsonObj.event !== 'sendToPropertyInspector') {
+            return;
+        }
+        var payload = jsonObj.payload || {};
+        var status = document.getElementById('cutListStatus');
+        if (!status) {
+            return;
+        }
+        if (payload.event === 'cutListExported') {
+            status.innerText = payload.error ? payload.error : 'Exported ' + payload.cuts + ' cuts to ' + payload.path;
+        }
+        else if (payload.event === 'cutListCleared') {
+            status.innerText = payload.error ? payload.error : 'Cleared ' + payload.cuts + ' cuts';
+        }
+    });
+});
+
+function exportCutList() {
+    // M/Eは1始まりで入力する
+    var me = parseInt(document.getElementById('cutListMe').value, 10) || 1;
+    sendPayloadToPlugin({
+        command: 'exportCutList',
+        format: document.getElementById('cutListFormat').value,
+        path: document.getElementById('cutListPath').value,
+        me: Math.max(me - 1, 0),
+        fps: parseInt(document.getElementById('cutListFps').value, 10) || 0
+    });
+}
+
+function clearCutList() {
+    sendPayloadToPlugin({
+        command: 'clearCutList'
+    });
+}
@@ -0,0 +1,88 @@
+// ****************************************************************
+// * 接続の診断情報の表示と診断データ(zip)の書き出しを行うProperty Inspector
+// *
+// * Property Inspectorを開いている間は定期的に診断情報を取得する
+// ****************************************************************
+
+var diagnosticsInterval = 2000;
+var diagnosticsTimer = null;
+
+document.addEventListener('websocketCreate', function () {
+    websocket.addEventListener('message', function (evt) {
+        var jsonObj = JSON.parse(evt.data);
+        if (jsonObj.event !== 'sendToPropertyInspector') {
+            return;
+        }
+        var payload = jsonObj.payload || {};
+        if (payload.event === 'diagnostics') {
+            renderDiagnostics(payload.report || {});
+        }
+        else if (payload.event === 'supportBundleExported') {
+            document.getElementById('bundleStatus').innerText = payload.error ? payload.error : 'Exported to ' + payload.path;
+        }
+    });
+
+    requestDiagnostics();
+    diagnosticsTimer = setInterval(requestDiagnostics, diagnosticsInterval);
+});
+
+function requestDiagnostics() {
+    sendPayloadToPlugin({ command: 'getDiagnostics' });
+}
+
+function exportSupportBundle() {
+    sendPayloadToPlugin({
+        command: 'exportSupportBundle',
+        path: document.getElementById('bundlePath').value
+    });
+}
+
+// formatTime 未記録(ゼロ値)の時刻は '-' にする
+function formatTime(value) {
+    if (!value || value.indexOf('0001-01-01') === 0) {
+        return '-';
+    }
+    return new Date(value).toLocaleString();
+}
+
+function renderDiagnostics(report) {
+    var root = document.getElementById('diagnostics');
+    root.innerHTML = '';
+    var switchers = report.switchers || [];
+    if (switchers.length === 0) {
+        root.textContent = '接続中のスイッチャーはありません';
+        return;
+    }
+
+    switchers.forEach(function (s) {
+        var rows = [
+            ['Host', s.host],
+            ['State', s.connected ? 'Connected' : 'Disconnected'],
+            ['Connected At', formatTime(s.connectedAt)],
+            ['Disconnects', s.disconnects + ' (last ' + formatTime(s.disconnectedAt) + ')'],
+            ['Last Error', s.lastError ? s.lastError + ' (' + formatTime(s.lastErrorAt) + ')' : '-']
+        ];
+        rows.push(['Product', (s.productName || '-') + ' (protocol ' + (s.protocolVersion || '-') + ')']);
+        // ATEMクライアントはパケットの送受信を集計しないため、往復時間とパケット数は表示できない
+        rows.push(['Statistics', '往復時間とパケット数は取得できません']);
+        rows.push(['Contexts', (s.contexts || []).map(function (c) {
+            return (c.switcher ? c.switcher + ' ' : '') + c.action.replace('dev.flowingspdg.atem.', '') + ' ' + c.context;
+        }).join('\n') || '-']);
+
+        rows.forEach(function (row) {
+            var item = document.createElement('div');
+            item.className = 'sdpi-item';
+            var label = document.createElement('div');
+            label.className = 'sdpi-item-label';
+            label.textContent = row[0];
+            var value = document.createElement('div');
+            value.className = 'sdpi-item-value';
+            value.style.whiteSpace = 'pre-wrap';
+            value.textContent = row[1];
+            item.appendChild(label);
+            item.appendChild(value);
+            root.appendChild(item);
+        });
+        root.appendChild(document.createElement('hr'));
+    });
+}
@@ -0,0 +1,55 @@
+// ****************************************************************
+// * プラグイン設定のエクスポート/インポートを行うProperty Inspector共通処理
+// *
+// * <textarea id="profileRemap"> で "旧IP=新IP" を1行ずつ指定する
+// ****************************************************************
+
+document.addEventListener('websocketCreate', function () {
+    websocket.addEventListener('message', function (evt) {
+        var jsonObj = JSON.parse(evt.data);
+        if (jsonObj.event !== 'sendToPropertyInspector') {
+            return;
+        }
+        var payload = jsonObj.payload || {};
+        var status = document.getElementById('profileStatus');
+        if (!status) {
+            return;
+        }
+        if (payload.event === 'profileExported') {
+            status.innerText = payload.error ? payload.error : 'Exported ' + payload.buttons + ' buttons to ' + payload.path;
+        }
+        else if (payload.event === 'profileImported') {
+            status.innerText = payload.error ? payload.error : 'Imported ' + payload.buttons + ' buttons from ' + payload.path;
+        }
+    });
+});
+
+function exportProfile() {
+    sendPayloadToPlugin({
+        command: 'exportProfile',
+        path: document.getElementById('profileExportPath').value
+    });
+}
+
+function importProfile() {
+    var file = document.getElementById('profileImportPath');
+    // Stream Deckのファイル選択はURLエンコードされたパスを返す
+    var path = decodeURIComponent(file.value.replace(/^C:\\fakepath\\/, ''));
+    if (!path) {
+        return;
+    }
+
+    var remap = {};
+    document.getElementById('profileRemap').value.split('\n').forEach(function (line) {
+        var pair = line.split('=');
+        if (pair.length === 2 && pair[0].trim()) {
+            remap[pair[0].trim()] = pair[1].trim();
+        }
+    });
+
+    sendPayloadToPlugin({
+        command: 'importProfile',
+        path: path,
+        remap: remap
+    });
+}
@@ -0,0 +1,166 @@
+// ****************************************************************
+// * ボタン以外の機能(グローバル設定)を扱うProperty Inspector共通処理
+// *
+// * <div id="services"> に services の定義に従って入力欄を並べ、
+// * 変更時にグローバル設定の globalSettings[key] として保存する
+// * switchers.js の後に読み込むこと
+// ****************************************************************
+
+var services = [
+    {
+        key: 'vmix',
+        title: 'vMix Tally',
+        fields: [
+            { name: 'enabled', label: 'Enabled', type: 'checkbox' },
+            { name: 'switcher', label: 'Switcher', type: 'text', placeholder: 'Main / 192.168.10.240' },
+            { name: 'meIndex', label: 'M/E', type: 'number', placeholder: '0' },
+            { name: 'inputs', label: 'Inputs', type: 'number', placeholder: '8' },
+            { name: 'tcpAddress', label: 'TCP', type: 'text', placeholder: ':8099' },
+            { name: 'httpAddress', label: 'HTTP', type: 'text', placeholder: ':8088' }
+        ]
+    },
+    {
+        key: 'tsl',
+        title: 'TSL UMD',
+        fields: [
+            { name: 'enabled', label: 'Enabled', type: 'checkbox' },
+            { name: 'switcher', label: 'Switcher', type: 'text', placeholder: 'Main / 192.168.10.240' },
+            { name: 'meIndex', label: 'M/E', type: 'number', placeholder: '0' },
+            { name: 'inputs', label: 'Inputs', type: 'number', placeholder: '8' },
+            { name: 'addressOffset', label: 'Address Offset', type: 'number', placeholder: '0' },
+            { name: 'destinations', label: 'Destinations', type: 'lines', placeholder: 'udp://192.168.10.50:40001?version=5.0\ntcp://192.168.10.51:9800?version=3.1' }
+        ]
+    },
+    {
+        key: 'api',
+        title: 'HTTP API',
+        fields: [
+            { name: 'enabled', label: 'Enabled', type: 'checkbox' },
+            { name: 'address', label: 'Bind Address', type: 'text', placeholder: '127.0.0.1:8765' },
+            { name: 'token', label: 'Token', type: 'text', placeholder: '(起動時に自動生成)' },
+            { name: 'allowedOrigins', label: 'Allowed Origins', type: 'lines', placeholder: '(ブラウザからの接続を拒否)\nlocalhost:3000' }
+        ]
+    },
+    {
+        key: 'osc',
+        title: 'OSC',
+        fields: [
+            { name: 'enabled', label: 'Enabled', type: 'checkbox' },
+            { name: 'address', label: 'Listen', type: 'text', placeholder: '127.0.0.1:9000' },
+            { name: 'targets', label: 'Feedback To', type: 'lines', placeholder: '192.168.10.60:53000' },
+            { name: 'feedback', label: 'Switchers', type: 'lines', placeholder: '(全ての名前付きスイッチャー)' }
+        ]
+    },
+    {
+        key: 'asrun',
+        title: 'As-Run Log',
+        fields: [
+            { name: 'enabled', label: 'Enabled', type: 'checkbox' },
+            { name: 'dir', label: 'Directory', type: 'text', placeholder: 'asrun' },
+            { name: 'maxSizeMB', label: 'Max Size (MB)', type: 'number', placeholder: '10' },
+            { name: 'maxAgeDays', label: 'Keep (days)', type: 'number', placeholder: '(無期限)' }
+        ]
+    },
+    {
+        key: 'log',
+        title: 'Plugin Log',
+        fields: [
+            { name: 'level', label: 'Level', type: 'text', placeholder: 'info (debug / info / warn / error)' }
+        ]
+    },
+    {
+        key: 'metrics',
+        title: 'Prometheus Metrics',
+        fields: [
+            { name: 'enabled', label: 'Enabled', type: 'checkbox' },
+            { name: 'address', label: 'Listen', type: 'text', placeholder: '127.0.0.1:9110' }
+        ]
+    },
+    {
+        key: 'lock',
+        title: 'Panel Lock',
+        fields: [
+            { name: 'rehearsal', label: 'Rehearsal To', type: 'text', placeholder: '(内蔵シミュレーター)' }
+        ]
+    }
+];
+
+document.addEventListener('websocketCreate', function () {
+    websocket.addEventListener('message', function (evt) {
+        var jsonObj = JSON.parse(evt.data);
+        if (jsonObj.event === 'didReceiveGlobalSettings') {
+            renderServices();
+        }
+    });
+});
+
+function renderServices() {
+    var root = document.getElementById('services');
+    if (!root) {
+        return;
+    }
+    root.innerHTML = '';
+
+    services.forEach(function (service) {
+        var values = globalSettings[service.key] || {};
+        var title = document.createElement('div');
+        title.className = 'sdpi-item-label';
+        title.textContent = service.title;
+        root.appendChild(title);
+
+        service.fields.forEach(function (field) {
+            var item = document.createElement('div');
+            item.className = 'sdpi-item';
+
+            var label = document.createElement('div');
+            label.className = 'sdpi-item-label';
+            label.textContent = field.label;
+            item.appendChild(label);
+
+            var input = document.createElement(field.type === 'lines' ? 'textarea' : 'input');
+            input.className = 'sdpi-item-value';
+            if (field.type === 'lines') {
+                input.placeholder = field.placeholder || '';
+                input.value = (values[field.name] || []).join('\n');
+            } else if (field.type === 'checkbox') {
+                input.type = field.type;
+                input.checked = !!values[field.name];
+            } else {
+                input.type = field.type;
+                input.placeholder = field.placeholder || '';
+                input.value = values[field.name] === undefined ? '' : values[field.name];
+            }
+            input.onchange = function () {
+                saveService(service.key, field, input);
+            };
+            item.appendChild(input);
+            root.appendChild(item);
+        });
+    });
+}
+
+function saveService(key, field, input) {
+    var values = globalSettings[key] || {};
+    if (field.type === 'checkbox') {
+        values[field.name] = input.checked;
+    } else if (field.type === 'lines') {
+        values[field.name] = input.value.split('\n').map(function (line) {
+            return line.trim();
+        }).filter(function (line) {
+            return line !== '';
+        });
+    } else if (field.type === 'number') {
+        var n = parseInt(input.value, 10);
+        if (isNaN(n)) {
+            delete values[field.name];
+        } else {
+            values[field.name] = n;
+        }
+    } else if (input.value === '') {
+        delete values[field.name];
+    } else {
+        values[field.name] = input.value;
+    }
+    globalSettings[key] = values;
+    saveGlobalSettings();
+}
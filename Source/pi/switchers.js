@@ -0,0 +1,101 @@
+// ****************************************************************
+// * 名前付きスイッチャー(グローバル設定)を扱うProperty Inspector共通処理
+// *
+// * <select id="switcher"> にスイッチャー名を並べ、
+// * <textarea id="switcherList"> で "名前=アドレス:ポート" を1行ずつ編集する
+// ****************************************************************
+
+var globalSettings = { switchers: [] },
+    selectedSwitcher = '';
+
+document.addEventListener('websocketCreate', function () {
+    selectedSwitcher = (actionInfo.payload.settings || {}).switcher || '';
+
+    websocket.addEventListener('open', function () {
+        websocket.send(JSON.stringify({
+            event: 'getGlobalSettings',
+            context: uuid
+        }));
+    });
+
+    websocket.addEventListener('message', function (evt) {
+        var jsonObj = JSON.parse(evt.data);
+        if (jsonObj.event === 'didReceiveSettings') {
+            selectedSwitcher = (jsonObj.payload.settings || {}).switcher || '';
+            renderSwitchers();
+        }
+        else if (jsonObj.event === 'didReceiveGlobalSettings') {
+            globalSettings = jsonObj.payload.settings || {};
+            globalSettings.switchers = globalSettings.switchers || [];
+            renderSwitchers();
+            renderSwitcherList();
+        }
+    });
+});
+
+function renderSwitchers() {
+    var select = document.getElementById('switcher');
+    if (!select) {
+        return;
+    }
+    select.innerHTML = '';
+
+    var direct = document.createElement('option');
+    direct.value = '';
+    direct.text = '(ATEM IPを使用)';
+    select.appendChild(direct);
+
+    globalSettings.switchers.forEach(function (s) {
+        var opt = document.createElement('option');
+        opt.value = s.name;
+        opt.text = s.name + ' (' + s.address + (s.port ? ':' + s.port : '') + ')';
+        select.appendChild(opt);
+    });
+    select.value = selectedSwitcher;
+}
+
+function renderSwitcherList() {
+    var list = document.getElementById('switcherList');
+    if (!list) {
+        return;
+    }
+    list.value = globalSettings.switchers.map(function (s) {
+        return s.name + '=' + s.address + (s.port ? ':' + s.port : '');
+    }).join('\n');
+}
+
+function selectSwitcher() {
+    selectedSwitcher = document.getElementById('switcher').value;
+    setSettings();
+}
+
+function saveSwitchers() {
+    var list = document.getElementById('switcherList');
+    var switchers = [];
+    list.value.split('\n').forEach(function (line) {
+        var pair = line.split('=');
+        if (pair.length !== 2 || !pair[0].trim()) {
+            return;
+        }
+        var host = pair[1].trim().split(':');
+        var s = { name: pair[0].trim(), address: host[0] };
+        if (host.length === 2 && parseInt(host[1], 10)) {
+            s.port = parseInt(host[1], 10);
+        }
+        switchers.push(s);
+    });
+
+    globalSettings.switchers = switchers;
+    saveGlobalSettings();
+    renderSwitchers();
+}
+
+function saveGlobalSettings() {
+    if (websocket && (websocket.readyState === 1)) {
+        websocket.send(JSON.stringify({
+            event: 'setGlobalSettings',
+            context: uuid,
+            payload: globalSettings
+        }));
+    }
+}